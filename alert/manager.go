@@ -0,0 +1,126 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/sirupsen/logrus"
+)
+
+type Manager struct {
+	storage storage.Storage
+}
+
+func NewManager(storage storage.Storage) *Manager {
+	return &Manager{
+		storage: storage,
+	}
+}
+
+// Evaluate checks alert rules of given fetcher against its latest history and persists state changes.
+// Only alerts which changed their state are returned, so callers are not notified on every run.
+// Logs go to given job logger, so they can be correlated with the run which triggered evaluation.
+func (m *Manager) Evaluate(ctx context.Context, logger logrus.FieldLogger, fetcher models.Fetcher, now time.Time) []models.Alert {
+	rules, err := m.storage.GetAlertRules(ctx, fetcher.TenantId, fetcher.Id)
+	if err != nil {
+		logger.WithError(err).Error("GetAlertRules error")
+		return nil
+	}
+
+	changed := make([]models.Alert, 0)
+	for i := range rules {
+		alert, err := m.evaluateRule(ctx, logger, &rules[i], fetcher, now)
+		if err != nil {
+			logger.WithError(err).WithField("rule_id", rules[i].Id).Error("Alert rule evaluation error")
+			continue
+		}
+		if alert != nil {
+			changed = append(changed, *alert)
+		}
+	}
+
+	return changed
+}
+
+func (m *Manager) evaluateRule(ctx context.Context, logger logrus.FieldLogger, rule *models.AlertRule, fetcher models.Fetcher, now time.Time) (*models.Alert, error) {
+	failing, err := m.check(ctx, rule, fetcher, now)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := m.storage.GetFiringAlert(ctx, rule.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case failing && alert == nil:
+		alert = &models.Alert{
+			RuleId:    rule.Id,
+			FetcherId: fetcher.Id,
+			State:     models.AlertFiring,
+			Message:   message(rule),
+			FiredAt:   now.Unix(),
+		}
+		if err = m.storage.AddAlert(ctx, alert); err != nil {
+			return nil, err
+		}
+		logger.WithField("rule_id", rule.Id).Warnf("Alert fired: %s", alert.Message)
+		return alert, nil
+	case !failing && alert != nil:
+		resolvedAt := now.Unix()
+		alert.State = models.AlertResolved
+		alert.ResolvedAt = &resolvedAt
+		if err = m.storage.ResolveAlert(ctx, alert); err != nil {
+			return nil, err
+		}
+		logger.WithField("rule_id", rule.Id).Infof("Alert resolved: %s", alert.Message)
+		return alert, nil
+	}
+
+	return nil, nil
+}
+
+func (m *Manager) check(ctx context.Context, rule *models.AlertRule, fetcher models.Fetcher, now time.Time) (bool, error) {
+	switch rule.Type {
+	case models.RuleConsecutiveFailures:
+		// history comes newest first, so these are the latest runs
+		history, err := m.storage.GetLatestHistory(ctx, fetcher.Id, rule.Failures)
+		if err != nil || len(history) < rule.Failures {
+			return false, err
+		}
+		for _, h := range history {
+			if h.Error == nil {
+				return false, nil
+			}
+		}
+		return true, nil
+	case models.RuleLatency:
+		window := now.Add(-time.Duration(rule.Minutes) * time.Minute)
+		// one run from before the window is loaded as well, so we know the whole window was slow
+		since := window.Add(-time.Duration(fetcher.Interval) * time.Second)
+		// history comes newest first, so its last run is the oldest one
+		history, err := m.storage.GetHistorySince(ctx, fetcher.Id, since.Unix())
+		if err != nil || len(history) == 0 || history[len(history)-1].CreatedAt > window.Unix() {
+			return false, err
+		}
+		for _, h := range history {
+			if h.Duration <= rule.Latency {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
+	return false, fmt.Errorf("unknown rule type %q", rule.Type)
+}
+
+func message(rule *models.AlertRule) string {
+	if rule.Type == models.RuleLatency {
+		return fmt.Sprintf("latency above %gs for %d minutes", rule.Latency, rule.Minutes)
+	}
+	return fmt.Sprintf("%d consecutive failures", rule.Failures)
+}
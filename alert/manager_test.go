@@ -0,0 +1,158 @@
+package alert
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+func TestManager_Evaluate(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	failed := "connection refused"
+	fetcher := models.Fetcher{Id: 1, TenantId: models.DefaultTenantId, Interval: 60}
+	failures := models.AlertRule{Id: 1, FetcherId: 1, Type: models.RuleConsecutiveFailures, Failures: 3}
+	latency := models.AlertRule{Id: 2, FetcherId: 1, Type: models.RuleLatency, Latency: 2, Minutes: 5}
+	firing := &models.Alert{Id: 7, RuleId: 1, FetcherId: 1, State: models.AlertFiring, Message: "3 consecutive failures", FiredAt: now.Unix() - 120}
+	resolvedAt := now.Unix()
+
+	// run returns history entry created ago before now
+	run := func(ago time.Duration, err *string, duration float64) models.History {
+		return models.History{FetcherId: 1, CreatedAt: now.Add(-ago).Unix(), Error: err, Duration: duration}
+	}
+
+	tests := []struct {
+		name    string
+		storage *mock.Storage
+		want    []models.Alert
+	}{
+		{
+			name: "positive_failures_fire",
+			storage: &mock.Storage{
+				AlertRules: []models.AlertRule{failures},
+				History:    []models.History{run(0, &failed, 1), run(time.Minute, &failed, 1), run(2*time.Minute, &failed, 1)},
+			},
+			want: []models.Alert{{RuleId: 1, FetcherId: 1, State: models.AlertFiring, Message: "3 consecutive failures", FiredAt: now.Unix()}},
+		},
+		{
+			name: "positive_failures_latest_runs_only",
+			storage: &mock.Storage{
+				AlertRules: []models.AlertRule{failures},
+				// the success is older than the latest three runs, given out of order
+				History: []models.History{run(3*time.Minute, nil, 1), run(time.Minute, &failed, 1), run(0, &failed, 1), run(2*time.Minute, &failed, 1)},
+			},
+			want: []models.Alert{{RuleId: 1, FetcherId: 1, State: models.AlertFiring, Message: "3 consecutive failures", FiredAt: now.Unix()}},
+		},
+		{
+			name: "positive_failures_not_fired_after_success",
+			storage: &mock.Storage{
+				AlertRules: []models.AlertRule{failures},
+				History:    []models.History{run(0, &failed, 1), run(time.Minute, nil, 1), run(2*time.Minute, &failed, 1), run(3*time.Minute, &failed, 1)},
+			},
+			want: []models.Alert{},
+		},
+		{
+			name: "positive_failures_insufficient_history",
+			storage: &mock.Storage{
+				AlertRules: []models.AlertRule{failures},
+				History:    []models.History{run(0, &failed, 1), run(time.Minute, &failed, 1)},
+			},
+			want: []models.Alert{},
+		},
+		{
+			name: "positive_failures_no_refire_while_firing",
+			storage: &mock.Storage{
+				AlertRules:  []models.AlertRule{failures},
+				History:     []models.History{run(0, &failed, 1), run(time.Minute, &failed, 1), run(2*time.Minute, &failed, 1)},
+				FiringAlert: firing,
+			},
+			want: []models.Alert{},
+		},
+		{
+			name: "positive_failures_resolve",
+			storage: &mock.Storage{
+				AlertRules:  []models.AlertRule{failures},
+				History:     []models.History{run(0, nil, 1), run(time.Minute, &failed, 1), run(2*time.Minute, &failed, 1)},
+				FiringAlert: firing,
+			},
+			want: []models.Alert{{Id: 7, RuleId: 1, FetcherId: 1, State: models.AlertResolved, Message: "3 consecutive failures", FiredAt: now.Unix() - 120, ResolvedAt: &resolvedAt}},
+		},
+		{
+			name: "positive_latency_fire",
+			storage: &mock.Storage{
+				AlertRules: []models.AlertRule{latency},
+				History:    []models.History{run(0, nil, 3), run(3*time.Minute, nil, 3), run(6*time.Minute, nil, 3)},
+			},
+			want: []models.Alert{{RuleId: 2, FetcherId: 1, State: models.AlertFiring, Message: "latency above 2s for 5 minutes", FiredAt: now.Unix()}},
+		},
+		{
+			name: "positive_latency_not_fired_after_fast_run",
+			storage: &mock.Storage{
+				AlertRules: []models.AlertRule{latency},
+				History:    []models.History{run(0, nil, 3), run(3*time.Minute, nil, 1), run(6*time.Minute, nil, 3)},
+			},
+			want: []models.Alert{},
+		},
+		{
+			name: "positive_latency_insufficient_history",
+			storage: &mock.Storage{
+				AlertRules: []models.AlertRule{latency},
+				// nothing from before the window, so it may not have been slow all the time
+				History: []models.History{run(0, nil, 3), run(3*time.Minute, nil, 3)},
+			},
+			want: []models.Alert{},
+		},
+		{
+			name: "negative_get_alert_rules_error",
+			storage: &mock.Storage{
+				AlertRules:       []models.AlertRule{failures},
+				History:          []models.History{run(0, &failed, 1), run(time.Minute, &failed, 1), run(2*time.Minute, &failed, 1)},
+				GetAlertRulesErr: true,
+			},
+			want: nil,
+		},
+		{
+			name: "negative_get_latest_history_error",
+			storage: &mock.Storage{
+				AlertRules:          []models.AlertRule{failures},
+				GetLatestHistoryErr: true,
+			},
+			want: []models.Alert{},
+		},
+		{
+			name: "negative_add_alert_error",
+			storage: &mock.Storage{
+				AlertRules:  []models.AlertRule{failures},
+				History:     []models.History{run(0, &failed, 1), run(time.Minute, &failed, 1), run(2*time.Minute, &failed, 1)},
+				AddAlertErr: true,
+			},
+			want: []models.Alert{},
+		},
+		{
+			name: "negative_resolve_alert_error",
+			storage: &mock.Storage{
+				AlertRules:      []models.AlertRule{failures},
+				History:         []models.History{run(0, nil, 1)},
+				FiringAlert:     firing,
+				ResolveAlertErr: true,
+			},
+			want: []models.Alert{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(tt.storage)
+
+			got := m.Evaluate(context.Background(), logger, fetcher, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
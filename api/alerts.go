@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/middleware"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ruleIdKey         = "ruleId"
+	alertRuleResource = "alert rule"
+)
+
+type AlertHandlers struct {
+	storage storage.Storage
+	logger  logrus.FieldLogger
+}
+
+func NewAlertHandlers(s storage.Storage, l logrus.FieldLogger) *AlertHandlers {
+	return &AlertHandlers{
+		storage: s,
+		logger:  l,
+	}
+}
+
+func (h *AlertHandlers) GetAlerts(c *gin.Context) {
+	alerts, err := h.storage.GetActiveAlerts(c.Request.Context(), middleware.CurrentTenant(c))
+	if err != nil {
+		handlePostgresError(c, h.logger, err, "alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+func (h *AlertHandlers) GetAlertRules(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - fetcher id"})
+		return
+	}
+
+	rules, err := h.storage.GetAlertRules(c.Request.Context(), middleware.CurrentTenant(c), id)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, fetcherResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *AlertHandlers) AddAlertRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - fetcher id"})
+		return
+	}
+
+	rule := &models.AlertRule{}
+	err = c.ShouldBindJSON(rule)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
+		return
+	}
+
+	if err = rule.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+
+	rule.FetcherId = id
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.AddAlertRule(c.Request.Context(), middleware.CurrentTenant(c), rule); err != nil {
+			return nil, err
+		}
+		return &models.AuditEntry{
+			Action:     models.AuditCreate,
+			Resource:   alertRuleResource,
+			ResourceId: strconv.Itoa(rule.Id),
+			FetcherId:  &id,
+			After:      *rule,
+		}, nil
+	})
+	if err != nil {
+		handlePostgresError(c, h.logger, err, fetcherResource)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Id{Id: rule.Id})
+}
+
+func (h *AlertHandlers) DeleteAlertRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - fetcher id"})
+		return
+	}
+
+	ruleId, err := strconv.Atoi(c.Param(ruleIdKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - rule id"})
+		return
+	}
+
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.DeleteAlertRule(c.Request.Context(), middleware.CurrentTenant(c), id, ruleId); err != nil {
+			return nil, err
+		}
+		return &models.AuditEntry{
+			Action:     models.AuditDelete,
+			Resource:   alertRuleResource,
+			ResourceId: strconv.Itoa(ruleId),
+			FetcherId:  &id,
+		}, nil
+	})
+	if err != nil {
+		handlePostgresError(c, h.logger, err, alertRuleResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{})
+}
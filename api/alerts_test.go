@@ -0,0 +1,335 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAlertHandlers_GetAlerts(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		logger  *logrus.Logger
+		conf    *config.Config
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		wantStatus int
+	}{
+		{
+			name: "positive_get_alerts",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_alerts_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					GetActiveAlertsErr: true,
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/api/alerts", nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestAlertHandlers_GetAlertRules(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		logger  *logrus.Logger
+		conf    *config.Config
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		fetcherId  string
+		wantStatus int
+	}{
+		{
+			name: "positive_get_alert_rules",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_alert_rules_invalid_id_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_alert_rules_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					GetAlertRulesErr: true,
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/api/fetcher/%s/rules", tt.fetcherId)
+			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestAlertHandlers_AddAlertRule(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		logger  *logrus.Logger
+		conf    *config.Config
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		fetcherId  string
+		body       interface{}
+		wantStatus int
+	}{
+		{
+			name: "positive_add_alert_rule",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId: validId,
+			body: &models.AlertRule{
+				Type:     models.RuleConsecutiveFailures,
+				Failures: 3,
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "negative_add_alert_rule_invalid_id_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId: invalidId,
+			body: &models.AlertRule{
+				Type:     models.RuleConsecutiveFailures,
+				Failures: 3,
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_add_alert_rule_invalid_body_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId: validId,
+			body: map[string]interface{}{
+				"type": 65,
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_add_alert_rule_validation_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId: validId,
+			body: &models.AlertRule{
+				Type: models.RuleLatency,
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_add_alert_rule_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					AddAlertRuleErr: true,
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId: validId,
+			body: &models.AlertRule{
+				Type:    models.RuleLatency,
+				Latency: 1.5,
+				Minutes: 5,
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithWorker(),
+			)
+
+			jsonBody, _ := json.Marshal(tt.body)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/api/fetcher/%s/rules", tt.fetcherId)
+			req, _ := http.NewRequest(http.MethodPost, reqUrl, bytes.NewBuffer(jsonBody))
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestAlertHandlers_DeleteAlertRule(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		logger  *logrus.Logger
+		conf    *config.Config
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		fetcherId  string
+		ruleId     string
+		wantStatus int
+	}{
+		{
+			name: "positive_delete_alert_rule",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  validId,
+			ruleId:     validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_delete_alert_rule_invalid_rule_id_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  validId,
+			ruleId:     invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_delete_alert_rule_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					DeleteAlertRuleErr: true,
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  validId,
+			ruleId:     validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/api/fetcher/%s/rules/%s", tt.fetcherId, tt.ruleId)
+			req, _ := http.NewRequest(http.MethodDelete, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
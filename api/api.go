@@ -1,15 +1,23 @@
 package api
 
 import (
-	"log"
+	"context"
 	"sync"
 
+	"github.com/BarTar213/bartlomiej-tarczynski/alert"
 	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/destination"
+	"github.com/BarTar213/bartlomiej-tarczynski/metrics"
 	"github.com/BarTar213/bartlomiej-tarczynski/middleware"
 	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/notifier"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
 	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/BarTar213/bartlomiej-tarczynski/tracing"
 	"github.com/BarTar213/bartlomiej-tarczynski/worker"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/api/trace"
 )
 
 type Api struct {
@@ -20,7 +28,12 @@ type Api struct {
 	HistoryPool *sync.Pool
 	FetcherPool *sync.Pool
 	Worker      *worker.Worker
-	Logger      *log.Logger
+	Dispatcher  *notifier.Dispatcher
+	Metrics     *metrics.Metrics
+	Tracer      trace.Tracer
+	Secrets     *secrets.Manager
+	Policy      *destination.Policy
+	Logger      *logrus.Logger
 }
 
 func WithConfig(conf *config.Config) func(a *Api) {
@@ -29,27 +42,82 @@ func WithConfig(conf *config.Config) func(a *Api) {
 	}
 }
 
-func WithLogger(logger *log.Logger) func(a *Api) {
+func WithLogger(logger *logrus.Logger) func(a *Api) {
 	return func(a *Api) {
 		a.Logger = logger
 	}
 }
 
-func WithStorage(storage storage.Storage) func(a *Api) {
+// WithTraceProvider replaces default no-op tracing, it has to be passed before WithStorage and WithWorker
+func WithTraceProvider(provider trace.Provider) func(a *Api) {
 	return func(a *Api) {
-		a.Storage = storage
+		a.Tracer = provider.Tracer(tracing.TracerName)
+	}
+}
+
+func WithStorage(s storage.Storage) func(a *Api) {
+	return func(a *Api) {
+		a.Storage = storage.NewTraced(s, a.Tracer)
+	}
+}
+
+// WithSecrets enables secret store, it has to be passed after WithStorage and before WithWorker.
+// Nil keyring leaves secret store disabled.
+func WithSecrets(keyring *secrets.Keyring) func(a *Api) {
+	return func(a *Api) {
+		a.Secrets = secrets.NewManager(a.Storage, keyring)
+	}
+}
+
+// WithDestinations restricts where fetchers may connect to, it has to be passed before WithWorker.
+// Without it every destination is allowed.
+func WithDestinations(policy *destination.Policy) func(a *Api) {
+	return func(a *Api) {
+		a.Policy = policy
 	}
 }
 
 func WithWorker() func(a *Api) {
 	return func(a *Api) {
-		a.Worker = worker.New(a.Storage, a.HistoryPool, a.Logger)
+		proxy := a.Config.Proxy
+		proxyUrl, err := a.Secrets.Resolve(context.Background(), models.DefaultTenantId, proxy.Url)
+		if err != nil {
+			a.Logger.WithError(err).Fatal("Proxy url error")
+		}
+		proxy.Url = proxyUrl
+
+		// notifications go through the same proxy and destination policy as fetcher requests
+		transport := worker.NewTransport(proxy, a.Policy)
+		notifiers := []notifier.Notifier{
+			notifier.NewWebhook(a.Storage, a.Secrets, a.Config.Webhook, transport, a.Logger),
+			notifier.NewSlack(a.Storage, a.Secrets, a.Config.Api.PublicUrl, transport, a.Logger),
+			notifier.NewTeams(a.Storage, a.Secrets, a.Config.Api.PublicUrl, transport, a.Logger),
+		}
+		if len(a.Config.Smtp.Host) > 0 {
+			smtp := a.Config.Smtp
+			password, err := a.Secrets.Resolve(context.Background(), models.DefaultTenantId, smtp.Password)
+			if err != nil {
+				a.Logger.WithError(err).Fatal("Smtp password error")
+			}
+			smtp.Password = password
+
+			email, err := notifier.NewEmail(a.Storage, smtp, a.Logger)
+			if err != nil {
+				a.Logger.WithError(err).Fatal("Email notifier error")
+			}
+			notifiers = append(notifiers, email)
+		}
+
+		a.Dispatcher = notifier.NewDispatcher(a.Logger, notifiers...)
+		a.Worker = worker.New(a.Storage, a.HistoryPool, alert.NewManager(a.Storage), a.Dispatcher, a.Metrics, a.Tracer, a.Secrets, proxy, a.Policy, a.Config.Tls.Dir, a.Config.Certs.WarningWindow(), a.Logger)
 	}
 }
 
 func NewApi(options ...func(api *Api)) *Api {
 	a := &Api{
-		Router: gin.Default(),
+		Router:  gin.New(),
+		Metrics: metrics.New(),
+		Tracer:  trace.NoopProvider{}.Tracer(tracing.TracerName),
 		HistoryPool: &sync.Pool{
 			New: func() interface{} {
 				return new(models.History)
@@ -66,17 +134,112 @@ func NewApi(options ...func(api *Api)) *Api {
 		option(a)
 	}
 
-	h := NewFetcherHandlers(a.Storage, a.Worker, a.FetcherPool, a.Logger)
+	h := NewFetcherHandlers(a.Storage, a.Worker, a.Dispatcher, a.FetcherPool, a.Secrets, a.Policy, a.Config.Certs.WarningWindow(), a.Logger)
+	ah := NewAlertHandlers(a.Storage, a.Logger)
+	wh := NewWebhookHandlers(a.Storage, a.Secrets, a.Policy, a.Logger)
+	sh := NewSecretHandlers(a.Storage, a.Secrets, a.Logger)
+	th := NewTenantHandlers(a.Storage, a.Logger)
+	auh := NewAuditHandlers(a.Storage, a.Logger)
+	hh := NewHealthHandlers(a.Storage, a.Worker, a.Logger)
+
+	adminKey, err := a.Secrets.Resolve(context.Background(), models.DefaultTenantId, a.Config.Api.AdminKey)
+	if err != nil {
+		a.Logger.WithError(err).Fatal("Admin key error")
+	}
+	kh := NewApiKeyHandlers(a.Storage, adminKey, a.Logger)
+
+	auth := middleware.Anonymous()
+	if a.Config.Api.RequireAuth {
+		auth = middleware.Authenticate(kh.Lookup)
+	}
+	read := middleware.RequireScope(models.ScopeFetchersRead)
+	write := middleware.RequireScope(models.ScopeFetchersWrite)
+	history := middleware.RequireScope(models.ScopeHistoryRead)
+	admin := middleware.RequireScope(models.ScopeAdmin)
+	operator := middleware.RequireOperator()
+	limits := a.Config.RateLimit
+	proxies, err := middleware.NewProxies(limits.TrustedProxies)
+	if err != nil {
+		a.Logger.WithError(err).Fatal("Trusted proxies error")
+	}
+	limit := middleware.RateLimit(
+		middleware.NewRateLimiter(limits.Read.PerMinute, limits.Read.Burst),
+		middleware.NewRateLimiter(limits.Write.PerMinute, limits.Write.Burst),
+		proxies,
+	)
+	// failed authentication is limited before the key is looked up, so keys can't be guessed
+	guard := middleware.LimitAuthFailures(middleware.NewRateLimiter(limits.Auth.PerMinute, limits.Auth.Burst), proxies)
+
+	a.Router.Use(
+		middleware.RequestId(),
+		middleware.Tracing(a.Tracer),
+		middleware.Logger(a.Logger),
+		gin.Recovery(),
+		middleware.RequestMetrics(a.Metrics),
+	)
+	a.Router.GET("/metrics", gin.WrapH(a.Metrics.Handler()))
+	a.Router.GET("/healthz", hh.Healthz)
+	a.Router.GET("/readyz", hh.Readyz)
+
+	fetchers := a.Router.Group("/api/fetcher", guard, auth, limit)
+	{
+		fetchers.GET("", read, h.GetFetchers)
+		fetchers.GET("/:id", read, h.GetFetcher)
+		fetchers.Use(middleware.CheckContentLength(a.Config.Api.MaxContentLength)).PUT("/:id", write, h.UpdateFetcher)
+		fetchers.Use(middleware.CheckContentLength(a.Config.Api.MaxContentLength)).PATCH("/:id", write, h.PatchFetcher)
+		fetchers.Use(middleware.CheckContentLength(a.Config.Api.MaxContentLength)).POST("", write, h.AddFetcher)
+		fetchers.DELETE("/:id", write, h.DeleteFetcher)
+		fetchers.POST("/:id/pause", write, h.PauseFetcher)
+		fetchers.POST("/:id/resume", write, h.ResumeFetcher)
+		fetchers.GET("/:id/revisions", read, h.GetRevisions)
+		fetchers.POST("/:id/revisions/:rev/restore", write, h.RestoreRevision)
+
+		fetchers.GET("/:id/history", history, h.GetHistory)
+		fetchers.GET("/:id/stats", history, h.GetStats)
 
-	a.Router.Use(gin.Recovery())
-	fetchers := a.Router.Group("/api/fetcher")
+		fetchers.GET("/:id/rules", read, ah.GetAlertRules)
+		fetchers.POST("/:id/rules", write, ah.AddAlertRule)
+		fetchers.DELETE("/:id/rules/:ruleId", write, ah.DeleteAlertRule)
+	}
+
+	bulk := a.Router.Group("/api/bulk/fetchers", guard, auth, limit, write)
 	{
-		fetchers.GET("", h.GetFetchers)
-		fetchers.Use(middleware.CheckContentLength(a.Config.Api.MaxContentLength)).PUT("/:id", h.UpdateFetcher)
-		fetchers.Use(middleware.CheckContentLength(a.Config.Api.MaxContentLength)).POST("", h.AddFetcher)
-		fetchers.DELETE("/:id", h.DeleteFetcher)
+		bulk.POST("/pause", h.BulkPause)
+		bulk.POST("/resume", h.BulkResume)
+		bulk.POST("/delete", h.BulkDelete)
+	}
 
-		fetchers.GET("/:id/history", h.GetHistory)
+	a.Router.GET("/api/alerts", guard, auth, limit, read, ah.GetAlerts)
+	a.Router.GET("/api/audit", guard, auth, limit, admin, auh.GetAuditLog)
+
+	webhooks := a.Router.Group("/api/webhooks", guard, auth, limit, admin)
+	{
+		webhooks.GET("", wh.GetWebhooks)
+		webhooks.Use(middleware.CheckContentLength(a.Config.Api.MaxContentLength)).POST("", wh.AddWebhook)
+		webhooks.DELETE("/:id", wh.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", wh.GetWebhookDeliveries)
+	}
+
+	secretsGroup := a.Router.Group("/api/secrets", guard, auth, limit, admin)
+	{
+		secretsGroup.GET("", sh.GetSecrets)
+		secretsGroup.POST("/rotate", operator, sh.RotateSecrets)
+		secretsGroup.Use(middleware.CheckContentLength(a.Config.Api.MaxContentLength)).PUT("/:name", sh.SetSecret)
+		secretsGroup.DELETE("/:name", sh.DeleteSecret)
+	}
+
+	keys := a.Router.Group("/api/keys", guard, auth, limit, admin)
+	{
+		keys.GET("", kh.GetApiKeys)
+		keys.Use(middleware.CheckContentLength(a.Config.Api.MaxContentLength)).POST("", kh.AddApiKey)
+		keys.DELETE("/:id", kh.RevokeApiKey)
+	}
+
+	tenants := a.Router.Group("/api/tenants", guard, auth, limit, operator)
+	{
+		tenants.GET("", th.GetTenants)
+		tenants.Use(middleware.CheckContentLength(a.Config.Api.MaxContentLength)).POST("", th.AddTenant)
+		tenants.PUT("/:id", th.UpdateTenant)
 	}
 
 	return a
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/middleware"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/go-pg/pg/v10"
+	"github.com/sirupsen/logrus"
+)
+
+const apiKeyResource = "api key"
+
+type ApiKeyHandlers struct {
+	storage   storage.Storage
+	adminHash string
+	logger    logrus.FieldLogger
+}
+
+// NewApiKeyHandlers takes optional admin key, which is accepted besides stored keys,
+// so the first keys can be created once authentication is required
+func NewApiKeyHandlers(s storage.Storage, adminKey string, l logrus.FieldLogger) *ApiKeyHandlers {
+	h := &ApiKeyHandlers{
+		storage: s,
+		logger:  l,
+	}
+	if len(adminKey) > 0 {
+		h.adminHash = models.HashApiKey(adminKey)
+	}
+
+	return h
+}
+
+// Lookup returns not revoked key matching given one, or nil if there is none
+func (h *ApiKeyHandlers) Lookup(ctx context.Context, key string) (*models.ApiKey, error) {
+	hash := models.HashApiKey(key)
+	if len(h.adminHash) > 0 && subtle.ConstantTimeCompare([]byte(hash), []byte(h.adminHash)) == 1 {
+		return &models.ApiKey{TenantId: models.DefaultTenantId, Name: "admin", Scopes: []string{models.ScopeAdmin}}, nil
+	}
+
+	apiKey, err := h.storage.GetApiKeyByHash(ctx, hash)
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return apiKey, err
+}
+
+func (h *ApiKeyHandlers) GetApiKeys(c *gin.Context) {
+	keys, err := h.storage.GetApiKeys(c.Request.Context(), middleware.CurrentTenant(c))
+	if err != nil {
+		handlePostgresError(c, h.logger, err, apiKeyResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// AddApiKey creates key with given name and scopes, the key is returned only in this response.
+// Keys belong to tenant of the caller, only operator may create them for another tenant.
+func (h *ApiKeyHandlers) AddApiKey(c *gin.Context) {
+	key := &models.ApiKey{}
+	err := c.ShouldBindJSON(key)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
+		return
+	}
+
+	caller := middleware.CurrentApiKey(c)
+	if key.TenantId == 0 || !caller.IsOperator() {
+		key.TenantId = caller.TenantId
+	}
+
+	if err = key.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+
+	if err = key.Generate(); err != nil {
+		requestLogger(c, h.logger).WithError(err).Error("Generate api key error")
+		c.JSON(http.StatusInternalServerError, models.Response{Error: "couldn't generate api key"})
+		return
+	}
+	key.CreatedAt = time.Now().Unix()
+	key.RevokedAt = nil
+
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.AddApiKey(c.Request.Context(), key); err != nil {
+			return nil, err
+		}
+		after := *key
+		after.Key = ""
+		return &models.AuditEntry{
+			Action:     models.AuditCreate,
+			Resource:   apiKeyResource,
+			ResourceId: strconv.Itoa(key.Id),
+			After:      after,
+		}, nil
+	})
+	if err != nil {
+		handlePostgresError(c, h.logger, err, apiKeyResource)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *ApiKeyHandlers) RevokeApiKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - api key id"})
+		return
+	}
+
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.RevokeApiKey(c.Request.Context(), middleware.CurrentTenant(c), id, time.Now().Unix()); err != nil {
+			return nil, err
+		}
+		return &models.AuditEntry{
+			Action:     models.AuditRevoke,
+			Resource:   apiKeyResource,
+			ResourceId: strconv.Itoa(id),
+		}, nil
+	})
+	if err != nil {
+		handlePostgresError(c, h.logger, err, apiKeyResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{})
+}
@@ -0,0 +1,364 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	adminKey       = "fk_admin"
+	readerKey      = "fk_reader"
+	tenantAdminKey = "fk_tenant_admin"
+)
+
+func newAuthApi(s *mock.Storage) *Api {
+	gin.SetMode(gin.ReleaseMode)
+	return NewApi(
+		WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024, RequireAuth: true, AdminKey: adminKey}}),
+		WithLogger(logger),
+		WithStorage(s),
+		WithWorker(),
+	)
+}
+
+func TestApi_Authentication(t *testing.T) {
+	revokedAt := int64(1600000000)
+	keys := []models.ApiKey{
+		{Id: 1, Name: "reader", Hash: models.HashApiKey(readerKey), Scopes: []string{models.ScopeFetchersRead}},
+		{Id: 2, Name: "revoked", Hash: models.HashApiKey("fk_revoked"), Scopes: []string{models.ScopeAdmin}, RevokedAt: &revokedAt},
+		{Id: 3, TenantId: 2, Name: "tenant admin", Hash: models.HashApiKey(tenantAdminKey), Scopes: []string{models.ScopeAdmin}},
+	}
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		method     string
+		reqUrl     string
+		header     string
+		wantStatus int
+	}{
+		{
+			name:       "negative_missing_key_error",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/api/fetcher",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "negative_invalid_key_error",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/api/fetcher",
+			header:     "Bearer fk_unknown",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "negative_revoked_key_error",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/api/fetcher",
+			header:     "Bearer fk_revoked",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "negative_basic_auth_error",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/api/fetcher",
+			header:     "Basic " + readerKey,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "positive_read_with_scope",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/api/fetcher",
+			header:     "bearer " + readerKey,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_write_without_scope_error",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodDelete,
+			reqUrl:     fmt.Sprintf("/api/fetcher/%s", validId),
+			header:     "Bearer " + readerKey,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "negative_history_without_scope_error",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     fmt.Sprintf("/api/fetcher/%s/history", validId),
+			header:     "Bearer " + readerKey,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "negative_admin_endpoint_without_scope_error",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/api/keys",
+			header:     "Bearer " + readerKey,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "positive_admin_key",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/api/keys",
+			header:     "Bearer " + adminKey,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "positive_tenant_admin_endpoint",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/api/keys",
+			header:     "Bearer " + tenantAdminKey,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_tenant_admin_operator_endpoint_error",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/api/tenants",
+			header:     "Bearer " + tenantAdminKey,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "negative_tenant_admin_rotate_secrets_error",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodPost,
+			reqUrl:     "/api/secrets/rotate",
+			header:     "Bearer " + tenantAdminKey,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "positive_operator_endpoint",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/api/tenants",
+			header:     "Bearer " + adminKey,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "positive_health_without_key",
+			storage:    &mock.Storage{ApiKeys: keys},
+			method:     http.MethodGet,
+			reqUrl:     "/healthz",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_lookup_storage_error",
+			storage:    &mock.Storage{GetApiKeyByHashErr: true},
+			method:     http.MethodGet,
+			reqUrl:     "/api/fetcher",
+			header:     "Bearer " + readerKey,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAuthApi(tt.storage)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.reqUrl, nil)
+			if len(tt.header) > 0 {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestApiKeyHandlers_AddApiKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		body       interface{}
+		wantStatus int
+	}{
+		{
+			name:       "positive_add_api_key",
+			storage:    &mock.Storage{},
+			body:       &models.ApiKey{Name: "ci", Scopes: []string{models.ScopeFetchersRead, models.ScopeHistoryRead}},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "negative_add_api_key_invalid_body_error",
+			storage:    &mock.Storage{},
+			body:       map[string]interface{}{"scopes": "admin"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_add_api_key_invalid_scope_error",
+			storage:    &mock.Storage{},
+			body:       &models.ApiKey{Name: "ci", Scopes: []string{"fetchers:delete"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_add_api_key_storage_error",
+			storage:    &mock.Storage{AddApiKeyErr: true},
+			body:       &models.ApiKey{Name: "ci", Scopes: []string{models.ScopeAdmin}},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAuthApi(tt.storage)
+
+			jsonBody, _ := json.Marshal(tt.body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/api/keys", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Authorization", "Bearer "+adminKey)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			key := &models.ApiKey{}
+			_ = json.Unmarshal(w.Body.Bytes(), key)
+			if !strings.HasPrefix(key.Key, key.Prefix) || len(tt.storage.ApiKeys) != 1 || tt.storage.ApiKeys[0].Hash != models.HashApiKey(key.Key) {
+				t.Fatalf("Expected key to be returned and its hash stored, got: %s", w.Body.String())
+			}
+			if bytes.Contains(w.Body.Bytes(), []byte(tt.storage.ApiKeys[0].Hash)) {
+				t.Errorf("Expected hash to be hidden, got: %s", w.Body.String())
+			}
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest(http.MethodGet, "/api/fetcher", nil)
+			req.Header.Set("Authorization", "Bearer "+key.Key)
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestApiKeyHandlers_GetApiKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		wantStatus int
+	}{
+		{
+			name:       "positive_get_api_keys",
+			storage:    &mock.Storage{},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_get_api_keys_storage_error",
+			storage:    &mock.Storage{GetApiKeysErr: true},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAuthApi(tt.storage)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/api/keys", nil)
+			req.Header.Set("Authorization", "Bearer "+adminKey)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestApiKeyHandlers_RevokeApiKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		keyId      string
+		wantStatus int
+	}{
+		{
+			name:       "positive_revoke_api_key",
+			storage:    &mock.Storage{},
+			keyId:      validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_revoke_api_key_invalid_id_error",
+			storage:    &mock.Storage{},
+			keyId:      invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_revoke_api_key_storage_error",
+			storage:    &mock.Storage{RevokeApiKeyErr: true},
+			keyId:      validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAuthApi(tt.storage)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api/keys/%s", tt.keyId), nil)
+			req.Header.Set("Authorization", "Bearer "+adminKey)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestApiKeyHandlers_AddApiKey_Tenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		caller     string
+		tenantId   int
+		wantTenant int
+	}{
+		{
+			name:       "positive_operator_key_for_tenant",
+			caller:     adminKey,
+			tenantId:   2,
+			wantTenant: 2,
+		},
+		{
+			name:       "positive_operator_key_for_own_tenant",
+			caller:     adminKey,
+			wantTenant: models.DefaultTenantId,
+		},
+		{
+			name:       "positive_tenant_admin_key_kept_in_own_tenant",
+			caller:     tenantAdminKey,
+			tenantId:   3,
+			wantTenant: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &mock.Storage{ApiKeys: []models.ApiKey{
+				{Id: 1, TenantId: 2, Name: "tenant admin", Hash: models.HashApiKey(tenantAdminKey), Scopes: []string{models.ScopeAdmin}},
+			}}
+			a := newAuthApi(s)
+
+			jsonBody, _ := json.Marshal(&models.ApiKey{TenantId: tt.tenantId, Name: "ci", Scopes: []string{models.ScopeFetchersRead}})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/api/keys", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Authorization", "Bearer "+tt.caller)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, http.StatusCreated, w.Code)
+			if got := s.ApiKeys[len(s.ApiKeys)-1].TenantId; got != tt.wantTenant {
+				t.Errorf("Expected key of tenant %d, got: %d", tt.wantTenant, got)
+			}
+		})
+	}
+}
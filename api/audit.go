@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/middleware"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	fetcherIdKey = "fetcher_id"
+	actorKey     = "actor"
+	sinceKey     = "since"
+	untilKey     = "until"
+	limitKey     = "limit"
+)
+
+type AuditHandlers struct {
+	storage storage.Storage
+	logger  logrus.FieldLogger
+}
+
+func NewAuditHandlers(s storage.Storage, l logrus.FieldLogger) *AuditHandlers {
+	return &AuditHandlers{
+		storage: s,
+		logger:  l,
+	}
+}
+
+// GetAuditLog returns audit entries of caller's tenant, filtered by fetcher_id, actor and since/until unix times
+func (h *AuditHandlers) GetAuditLog(c *gin.Context) {
+	filter := &models.AuditFilter{
+		Actor: c.Query(actorKey),
+		Limit: models.DefaultAuditLimit,
+	}
+
+	var err error
+	if param, ok := c.GetQuery(fetcherIdKey); ok {
+		filter.FetcherId, err = strconv.Atoi(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - fetcher id"})
+			return
+		}
+	}
+	if param, ok := c.GetQuery(sinceKey); ok {
+		filter.Since, err = strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - since"})
+			return
+		}
+	}
+	if param, ok := c.GetQuery(untilKey); ok {
+		filter.Until, err = strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - until"})
+			return
+		}
+	}
+	if param, ok := c.GetQuery(limitKey); ok {
+		filter.Limit, err = strconv.Atoi(param)
+		if err != nil || filter.Limit <= 0 || filter.Limit > models.MaxAuditLimit {
+			c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - limit"})
+			return
+		}
+	}
+
+	entries, err := h.storage.GetAuditLog(c.Request.Context(), middleware.CurrentTenant(c), filter)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, "audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// audited runs change and records entry it returns in the same transaction, so no change is stored without
+// its entry. Change gets storage bound to the transaction and has to make its calls on it.
+func audited(c *gin.Context, s storage.Storage, change func(s storage.Storage) (*models.AuditEntry, error)) error {
+	return s.RunInTransaction(c.Request.Context(), func(tx storage.Storage) error {
+		entry, err := change(tx)
+		if err != nil {
+			return err
+		}
+		return audit(c, tx, entry)
+	})
+}
+
+// audit records change made with api key of the request
+func audit(c *gin.Context, s storage.Storage, entry *models.AuditEntry) error {
+	key := middleware.CurrentApiKey(c)
+	entry.TenantId = key.TenantId
+	entry.Actor = key.Actor()
+	if key.Id > 0 {
+		id := key.Id
+		entry.ApiKeyId = &id
+	}
+	entry.CreatedAt = time.Now().Unix()
+
+	// chat webhook urls carry their credentials, so they are left out of the log
+	for _, state := range []*interface{}{&entry.Before, &entry.After} {
+		if fetcher, ok := (*state).(models.Fetcher); ok {
+			fetcher.RedactWebhooks()
+			*state = fetcher
+		}
+	}
+
+	return s.AddAuditEntry(c.Request.Context(), entry)
+}
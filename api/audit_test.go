@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditHandlers_GetAuditLog(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		query      string
+		wantStatus int
+	}{
+		{
+			name:       "positive_get_audit_log",
+			storage:    &mock.Storage{},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "positive_get_audit_log_filtered",
+			storage:    &mock.Storage{},
+			query:      "?fetcher_id=1&actor=fk_abcde&since=1600000000&until=1700000000&limit=10",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_get_audit_log_invalid_fetcher_id_error",
+			storage:    &mock.Storage{},
+			query:      "?fetcher_id=one",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_audit_log_invalid_since_error",
+			storage:    &mock.Storage{},
+			query:      "?since=yesterday",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_audit_log_invalid_until_error",
+			storage:    &mock.Storage{},
+			query:      "?until=now",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_audit_log_limit_too_large_error",
+			storage:    &mock.Storage{},
+			query:      "?limit=5000",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_audit_log_storage_error",
+			storage:    &mock.Storage{GetAuditLogErr: true},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTenantsApi(tt.storage)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/api/audit"+tt.query, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestAudit_RecordsChanges(t *testing.T) {
+	s := &mock.Storage{}
+	gin.SetMode(gin.ReleaseMode)
+	a := NewApi(
+		WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+		WithLogger(logger),
+		WithStorage(s),
+		WithSecrets(testKeyring),
+		WithWorker(),
+	)
+
+	requests := []struct {
+		method string
+		reqUrl string
+		body   interface{}
+	}{
+		{http.MethodPost, "/api/fetcher", &models.Fetcher{Url: exampleUrl, Interval: 60}},
+		{http.MethodPut, fmt.Sprintf("/api/fetcher/%s", validId), &models.Fetcher{Url: exampleUrl, Interval: 120}},
+		{http.MethodPost, fmt.Sprintf("/api/fetcher/%s/pause", validId), nil},
+		{http.MethodDelete, fmt.Sprintf("/api/fetcher/%s", validId), nil},
+		{http.MethodPost, "/api/webhooks", &models.Webhook{Url: exampleUrl, Secret: "s3cret", Events: []string{models.EventFetchFailed}}},
+	}
+	for _, r := range requests {
+		var body []byte
+		if r.body != nil {
+			body, _ = json.Marshal(r.body)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(r.method, r.reqUrl, bytes.NewBuffer(body))
+		a.Router.ServeHTTP(w, req)
+		if w.Code >= http.StatusBadRequest {
+			t.Fatalf("%s %s failed with %d: %s", r.method, r.reqUrl, w.Code, w.Body.String())
+		}
+	}
+
+	wantActions := []string{models.AuditCreate, models.AuditUpdate, models.AuditPause, models.AuditDelete, models.AuditCreate}
+	if len(s.AuditLog) != len(wantActions) {
+		t.Fatalf("Expected %d audit entries, got: %d", len(wantActions), len(s.AuditLog))
+	}
+	for i, entry := range s.AuditLog {
+		if entry.Action != wantActions[i] || entry.Actor != "anonymous" || entry.TenantId != models.DefaultTenantId || entry.CreatedAt == 0 {
+			t.Errorf("Unexpected audit entry %d: %+v", i, entry)
+		}
+	}
+
+	update := s.AuditLog[1]
+	if update.Before == nil || update.After.(models.Fetcher).Interval != 120 || strconv.Itoa(*update.FetcherId) != validId {
+		t.Errorf("Expected update entry with fetcher states, got: %+v", update)
+	}
+	if webhook := s.AuditLog[4].After.(models.Webhook); len(webhook.Secret) > 0 {
+		t.Errorf("Expected webhook secret to be left out, got: %s", webhook.Secret)
+	}
+}
+
+func TestAudit_StorageErrorRollsBackChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		reqUrl string
+		body   string
+	}{
+		{name: "negative_add_fetcher_audit_error", method: http.MethodPost, reqUrl: "/api/fetcher", body: fmt.Sprintf(`{"url":%q,"interval":60}`, exampleUrl)},
+		{name: "negative_delete_fetcher_audit_error", method: http.MethodDelete, reqUrl: fmt.Sprintf("/api/fetcher/%s", validId)},
+		{name: "negative_pause_fetcher_audit_error", method: http.MethodPost, reqUrl: fmt.Sprintf("/api/fetcher/%s/pause", validId)},
+		{name: "negative_delete_webhook_audit_error", method: http.MethodDelete, reqUrl: "/api/webhooks/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &mock.Storage{AddAuditEntryErr: true}
+			a := newTenantsApi(s)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.reqUrl, bytes.NewBufferString(tt.body))
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, http.StatusInternalServerError, w.Code)
+			if len(s.AuditLog) > 0 {
+				t.Errorf("Expected no audit entries, got: %+v", s.AuditLog)
+			}
+		})
+	}
+}
+
+func TestAudit_RecordsApiKeyId(t *testing.T) {
+	s := &mock.Storage{
+		ApiKeys: []models.ApiKey{
+			{Id: 1, TenantId: 2, Name: "tenant admin", Prefix: "fk_tenan", Hash: models.HashApiKey(tenantAdminKey), Scopes: []string{models.ScopeAdmin}},
+		},
+	}
+	a := newAuthApi(s)
+
+	for _, key := range []string{tenantAdminKey, adminKey} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/fetcher", bytes.NewBufferString(fmt.Sprintf(`{"url":%q,"interval":60}`, exampleUrl)))
+		req.Header.Set("Authorization", "Bearer "+key)
+		a.Router.ServeHTTP(w, req)
+		checkResponseStatusCode(t, http.StatusCreated, w.Code)
+	}
+
+	if len(s.AuditLog) != 2 {
+		t.Fatalf("Expected 2 audit entries, got: %d", len(s.AuditLog))
+	}
+	if entry := s.AuditLog[0]; entry.ApiKeyId == nil || *entry.ApiKeyId != 1 || entry.Actor != "fk_tenan" {
+		t.Errorf("Expected entry of stored key 1, got: %+v", entry)
+	}
+	// bootstrap admin key isn't stored, so it has no id
+	if entry := s.AuditLog[1]; entry.ApiKeyId != nil {
+		t.Errorf("Expected entry without api key id, got: %d", *entry.ApiKeyId)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/middleware"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/gin-gonic/gin"
+)
+
+// BulkPause pauses fetchers whose labels match selector query param
+func (h *FetcherHandlers) BulkPause(c *gin.Context) {
+	h.bulk(c, func(fetcher *models.Fetcher) error {
+		return h.changePaused(c, fetcher, true)
+	})
+}
+
+// BulkResume resumes fetchers whose labels match selector query param
+func (h *FetcherHandlers) BulkResume(c *gin.Context) {
+	h.bulk(c, func(fetcher *models.Fetcher) error {
+		return h.changePaused(c, fetcher, false)
+	})
+}
+
+// BulkDelete deletes fetchers whose labels match selector query param
+func (h *FetcherHandlers) BulkDelete(c *gin.Context) {
+	h.bulk(c, func(fetcher *models.Fetcher) error {
+		return h.remove(c, fetcher)
+	})
+}
+
+// bulk applies operation to every fetcher selected by required selector query param and responds with their ids.
+// Selector needs at least one positive requirement. Failed operation stops the rest, fetchers handled before
+// stay changed and their ids are returned together with the error.
+func (h *FetcherHandlers) bulk(c *gin.Context, operation func(fetcher *models.Fetcher) error) {
+	selector, err := models.ParseSelector(c.Query(selectorKey))
+	if err != nil || len(selector) == 0 {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - selector"})
+		return
+	}
+	if !selector.Narrowing() {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "selector needs at least one key=value or key requirement"})
+		return
+	}
+
+	fetchers, err := h.storage.GetFetchersBySelector(c.Request.Context(), middleware.CurrentTenant(c), selector)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, fetcherResource)
+		return
+	}
+
+	ids := make([]int, 0, len(fetchers))
+	for i := range fetchers {
+		if err = operation(&fetchers[i]); err != nil {
+			status, msg := h.operationError(c, err)
+			c.JSON(status, models.Ids{Ids: ids, Error: msg})
+			return
+		}
+		ids = append(ids, fetchers[i].Id)
+	}
+
+	c.JSON(http.StatusOK, models.Ids{Ids: ids})
+}
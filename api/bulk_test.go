@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestFetcherHandlers_Bulk(t *testing.T) {
+	fetchers := func() []models.Fetcher {
+		return []models.Fetcher{
+			{Id: 1, TenantId: 1, Url: exampleUrl, Interval: 60, Labels: map[string]string{"env": "prod", "team": "payments"}},
+			{Id: 2, TenantId: 1, Url: exampleUrl, Interval: 60, Labels: map[string]string{"env": "prod"}, Paused: true},
+			{Id: 3, TenantId: 1, Url: exampleUrl, Interval: 60, Labels: map[string]string{"env": "staging"}},
+		}
+	}
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		url        string
+		wantStatus int
+		wantIds    []int
+	}{
+		{
+			name:       "positive_bulk_pause",
+			storage:    &mock.Storage{Fetchers: fetchers()},
+			url:        "/api/bulk/fetchers/pause?selector=env=prod",
+			wantStatus: http.StatusOK,
+			wantIds:    []int{1, 2},
+		},
+		{
+			name:       "positive_bulk_resume",
+			storage:    &mock.Storage{Fetchers: fetchers()},
+			url:        "/api/bulk/fetchers/resume?selector=env,!team",
+			wantStatus: http.StatusOK,
+			wantIds:    []int{2, 3},
+		},
+		{
+			name:       "positive_bulk_delete",
+			storage:    &mock.Storage{Fetchers: fetchers()},
+			url:        "/api/bulk/fetchers/delete?selector=env,env!=prod",
+			wantStatus: http.StatusOK,
+			wantIds:    []int{3},
+		},
+		{
+			name:       "positive_bulk_pause_nothing_selected",
+			storage:    &mock.Storage{Fetchers: fetchers()},
+			url:        "/api/bulk/fetchers/pause?selector=env=dev",
+			wantStatus: http.StatusOK,
+			wantIds:    []int{},
+		},
+		{
+			name:       "negative_bulk_pause_missing_selector_error",
+			storage:    &mock.Storage{Fetchers: fetchers()},
+			url:        "/api/bulk/fetchers/pause",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_bulk_delete_invalid_selector_error",
+			storage:    &mock.Storage{Fetchers: fetchers()},
+			url:        "/api/bulk/fetchers/delete?selector=env=prod,",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_bulk_delete_not_equals_only_error",
+			storage:    &mock.Storage{Fetchers: fetchers()},
+			url:        "/api/bulk/fetchers/delete?selector=env!=prod",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_bulk_delete_not_exists_only_error",
+			storage:    &mock.Storage{Fetchers: fetchers()},
+			url:        "/api/bulk/fetchers/delete?selector=!nonexistent",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_bulk_pause_storage_error",
+			storage:    &mock.Storage{Fetchers: fetchers(), GetFetchersBySelectorErr: true},
+			url:        "/api/bulk/fetchers/pause?selector=env=prod",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "negative_bulk_pause_set_paused_error",
+			storage:    &mock.Storage{Fetchers: fetchers(), SetFetcherPausedErr: true},
+			url:        "/api/bulk/fetchers/pause?selector=env=prod",
+			wantStatus: http.StatusInternalServerError,
+			wantIds:    []int{},
+		},
+		{
+			name:       "negative_bulk_resume_partial_error",
+			storage:    &mock.Storage{Fetchers: fetchers(), SetFetcherPausedErr: true},
+			url:        "/api/bulk/fetchers/resume?selector=env=prod",
+			wantStatus: http.StatusInternalServerError,
+			wantIds:    []int{1},
+		},
+		{
+			name:       "negative_bulk_delete_storage_error",
+			storage:    &mock.Storage{Fetchers: fetchers(), DeleteFetcherErr: true},
+			url:        "/api/bulk/fetchers/delete?selector=env",
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(tt.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, tt.url, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+			if tt.wantIds == nil {
+				return
+			}
+
+			got := &models.Ids{}
+			_ = json.Unmarshal(w.Body.Bytes(), got)
+			if !reflect.DeepEqual(got.Ids, tt.wantIds) {
+				t.Errorf("Expected ids %v, got: %v", tt.wantIds, got.Ids)
+			}
+			if (tt.wantStatus != http.StatusOK) != (len(got.Error) > 0) {
+				t.Errorf("Unexpected error %q for status code %d", got.Error, w.Code)
+			}
+		})
+	}
+}
@@ -1,51 +1,195 @@
 package api
 
 import (
-	"log"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/BarTar213/bartlomiej-tarczynski/destination"
+	"github.com/BarTar213/bartlomiej-tarczynski/middleware"
 	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/notifier"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
 	"github.com/BarTar213/bartlomiej-tarczynski/storage"
 	"github.com/BarTar213/bartlomiej-tarczynski/worker"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 const (
-	idKey           = "id"
-	fetcherResource = "fetcher"
+	idKey            = "id"
+	revisionKey      = "rev"
+	windowKey        = "window"
+	fetcherResource  = "fetcher"
+	revisionResource = "fetcher revision"
+
+	urlFilterKey   = "url"
+	selectorKey    = "selector"
+	hostKey        = "host"
+	enabledKey     = "enabled"
+	lastStatusKey  = "last_status"
+	minIntervalKey = "interval_min"
+	maxIntervalKey = "interval_max"
+	sortKey        = "sort"
+	cursorKey      = "cursor"
+
+	totalCountHeader = "X-Total-Count"
+
+	defaultStatsWindow = 24 * time.Hour
+
+	fetcherNameIndex     = "fetchers_tenant_id_name_uindex"
+	fetcherRevisionIndex = "fetcher_revisions_pk"
 
 	invalidBodyErr = "invalid body"
+	fetcherNameErr = "fetcher with given name already exists"
 	registerJobErr = "couldn't register job associated with fetcher"
+	revisionErr    = "fetcher was changed meanwhile, try again"
+	authSecretErr  = "auth secret of the revision isn't stored anymore, update fetcher with it instead"
 )
 
+// errRegisterJob is returned by operations on fetchers when job couldn't be registered, cause is logged by them
+var errRegisterJob = errors.New(registerJobErr)
+
 type FetcherHandlers struct {
 	storage     storage.Storage
 	worker      *worker.Worker
+	dispatcher  *notifier.Dispatcher
 	fetcherPool *sync.Pool
-	logger      *log.Logger
+	secrets     *secrets.Manager
+	policy      *destination.Policy
+	certWarning time.Duration
+	logger      logrus.FieldLogger
 }
 
-func NewFetcherHandlers(s storage.Storage, w *worker.Worker, pool *sync.Pool, l *log.Logger) *FetcherHandlers {
+func NewFetcherHandlers(s storage.Storage, w *worker.Worker, d *notifier.Dispatcher, pool *sync.Pool, sm *secrets.Manager, policy *destination.Policy, certWarning time.Duration, l logrus.FieldLogger) *FetcherHandlers {
 	return &FetcherHandlers{
 		storage:     s,
 		worker:      w,
+		dispatcher:  d,
 		fetcherPool: pool,
+		secrets:     sm,
+		policy:      policy,
+		certWarning: certWarning,
 		logger:      l,
 	}
 }
 
+// GetFetchers returns page of fetchers matching query params. Total number of matching fetchers is returned
+// in X-Total-Count header and following page is linked in Link header.
 func (h *FetcherHandlers) GetFetchers(c *gin.Context) {
-	fetchers, err := h.storage.GetFetchers()
+	filter, err := fetcherFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+
+	// one more fetcher tells whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	fetchers, total, err := h.storage.GetFetchers(c.Request.Context(), middleware.CurrentTenant(c), filter)
 	if err != nil {
 		handlePostgresError(c, h.logger, err, fetcherResource)
 		return
 	}
 
+	links := []string{pageLink(c, "", "first")}
+	if len(fetchers) > limit {
+		fetchers = fetchers[:limit]
+		links = append(links, pageLink(c, filter.NextCursor(&fetchers[limit-1]), "next"))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+	c.Header(totalCountHeader, strconv.Itoa(total))
+
+	now := time.Now()
+	for i := range fetchers {
+		fetchers[i].SetCertWarning(now, h.certWarning)
+		fetchers[i].RedactWebhooks()
+	}
+
 	c.JSON(http.StatusOK, fetchers)
 }
 
+func fetcherFilter(c *gin.Context) (*models.FetcherFilter, error) {
+	filter := &models.FetcherFilter{
+		Name:       c.Query(nameKey),
+		Url:        c.Query(urlFilterKey),
+		Host:       c.Query(hostKey),
+		LastStatus: c.Query(lastStatusKey),
+		Sort:       c.Query(sortKey),
+		Limit:      models.DefaultFetcherLimit,
+	}
+
+	var err error
+	if filter.Selector, err = models.ParseSelector(c.Query(selectorKey)); err != nil {
+		return nil, errors.New("invalid query param - selector")
+	}
+	if param, ok := c.GetQuery(enabledKey); ok {
+		enabled, err := strconv.ParseBool(param)
+		if err != nil {
+			return nil, errors.New("invalid query param - enabled")
+		}
+		filter.Enabled = &enabled
+	}
+	if param, ok := c.GetQuery(minIntervalKey); ok {
+		if filter.MinInterval, err = strconv.Atoi(param); err != nil {
+			return nil, errors.New("invalid query param - interval_min")
+		}
+	}
+	if param, ok := c.GetQuery(maxIntervalKey); ok {
+		if filter.MaxInterval, err = strconv.Atoi(param); err != nil {
+			return nil, errors.New("invalid query param - interval_max")
+		}
+	}
+	if param, ok := c.GetQuery(limitKey); ok {
+		if filter.Limit, err = strconv.Atoi(param); err != nil {
+			return nil, errors.New("invalid query param - limit")
+		}
+	}
+	if param, ok := c.GetQuery(cursorKey); ok {
+		if filter.Cursor, err = models.ParseFetcherCursor(param); err != nil {
+			return nil, err
+		}
+	}
+
+	return filter, filter.Validate()
+}
+
+// pageLink returns Link header entry with url of the current request, whose cursor is replaced with given one
+func pageLink(c *gin.Context, cursor, rel string) string {
+	query := c.Request.URL.Query()
+	query.Del(cursorKey)
+	if len(cursor) > 0 {
+		query.Set(cursorKey, cursor)
+	}
+	page := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+
+	return fmt.Sprintf(`<%s>; rel="%s"`, page.String(), rel)
+}
+
+func (h *FetcherHandlers) GetFetcher(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - fetcher id"})
+		return
+	}
+
+	fetcher, err := h.storage.GetFetcher(c.Request.Context(), middleware.CurrentTenant(c), id)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, fetcherResource)
+		return
+	}
+	fetcher.SetCertWarning(time.Now(), h.certWarning)
+	fetcher.RedactWebhooks()
+
+	c.JSON(http.StatusOK, fetcher)
+}
+
 func (h *FetcherHandlers) AddFetcher(c *gin.Context) {
 	fetcher := h.fetcherPool.Get().(*models.Fetcher)
 	defer h.ReturnFetcher(fetcher)
@@ -55,31 +199,72 @@ func (h *FetcherHandlers) AddFetcher(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
 		return
 	}
+	fetcher.CertExpiresAt = nil
+	fetcher.TenantId = middleware.CurrentTenant(c)
 
 	if err = fetcher.Validate(); err != nil {
 		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
 		return
 	}
-
-	err = h.storage.AddFetcher(fetcher)
-	if err != nil {
-		handlePostgresError(c, h.logger, err, fetcherResource)
+	if err = h.checkDestination(c, fetcher); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
 		return
 	}
-
-	err = h.worker.RegisterJob(fetcher)
-	if err != nil {
-		h.logger.Printf("Job registration err: %s", err)
-		c.JSON(http.StatusInternalServerError, models.Response{Error: registerJobErr})
+	if fetcher.Auth != nil && !sealSecret(c, h.logger, h.secrets, &fetcher.Auth.Secret) {
 		return
 	}
 
-	err = h.storage.UpdateFetcherJobId(fetcher.Id, fetcher.JobId)
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.AddFetcher(c.Request.Context(), fetcher); err != nil {
+			return nil, err
+		}
+		// sealed auth secret is write-only, it doesn't leave the handler
+		fetcher.TakeSecret()
+
+		id := fetcher.Id
+		return &models.AuditEntry{
+			Action:     models.AuditCreate,
+			Resource:   fetcherResource,
+			ResourceId: strconv.Itoa(id),
+			FetcherId:  &id,
+			After:      *fetcher,
+		}, nil
+	})
+	if errors.Is(err, models.ErrQuotaExceeded) {
+		c.JSON(http.StatusForbidden, models.Response{Error: err.Error()})
+		return
+	}
+	if isUniqueViolation(err, fetcherNameIndex) {
+		c.JSON(http.StatusBadRequest, models.Response{Error: fetcherNameErr})
+		return
+	}
 	if err != nil {
 		handlePostgresError(c, h.logger, err, fetcherResource)
 		return
 	}
 
+	if !fetcher.Paused {
+		err = h.worker.RegisterJob(fetcher)
+		if err != nil {
+			requestLogger(c, h.logger).WithError(err).Error("Job registration error")
+			c.JSON(http.StatusInternalServerError, models.Response{Error: registerJobErr})
+			return
+		}
+
+		err = h.storage.UpdateFetcherJobId(c.Request.Context(), fetcher.Id, fetcher.JobId)
+		if err != nil {
+			handlePostgresError(c, h.logger, err, fetcherResource)
+			return
+		}
+	}
+
+	h.dispatcher.Dispatch(models.Event{
+		Type:      models.EventFetcherCreated,
+		TenantId:  fetcher.TenantId,
+		FetcherId: fetcher.Id,
+		Url:       fetcher.Url,
+	})
+
 	c.JSON(http.StatusCreated, models.Id{Id: fetcher.Id})
 }
 
@@ -90,16 +275,68 @@ func (h *FetcherHandlers) DeleteFetcher(c *gin.Context) {
 		return
 	}
 
-	jobId, err := h.storage.DeleteFetcher(id)
+	tenantId := middleware.CurrentTenant(c)
+	before, err := h.storage.GetFetcher(c.Request.Context(), tenantId, id)
 	if err != nil {
 		handlePostgresError(c, h.logger, err, fetcherResource)
 		return
 	}
-	go h.worker.DeregisterJob(jobId)
+
+	if err = h.remove(c, before); err != nil {
+		h.handleOperationError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, models.Response{})
 }
 
+// remove deletes fetcher together with its job
+func (h *FetcherHandlers) remove(c *gin.Context, fetcher *models.Fetcher) error {
+	id := fetcher.Id
+	jobId := 0
+	err := audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		var err error
+		jobId, err = s.DeleteFetcher(c.Request.Context(), fetcher.TenantId, id)
+		if err != nil {
+			return nil, err
+		}
+		return &models.AuditEntry{
+			Action:     models.AuditDelete,
+			Resource:   fetcherResource,
+			ResourceId: strconv.Itoa(id),
+			FetcherId:  &id,
+			Before:     *fetcher,
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+	h.worker.DeregisterJob(jobId)
+	h.worker.Forget(id)
+
+	h.dispatcher.Dispatch(models.Event{
+		Type:      models.EventFetcherDeleted,
+		TenantId:  fetcher.TenantId,
+		FetcherId: id,
+	})
+
+	return nil
+}
+
+// handleOperationError responds with error returned by remove or changePaused
+func (h *FetcherHandlers) handleOperationError(c *gin.Context, err error) {
+	status, msg := h.operationError(c, err)
+	c.JSON(status, models.Response{Error: msg})
+}
+
+func (h *FetcherHandlers) operationError(c *gin.Context, err error) (int, string) {
+	if err == errRegisterJob {
+		return http.StatusInternalServerError, registerJobErr
+	}
+
+	return postgresError(c, h.logger, err, fetcherResource)
+}
+
 func (h *FetcherHandlers) UpdateFetcher(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param(idKey))
 	if err != nil {
@@ -113,35 +350,323 @@ func (h *FetcherHandlers) UpdateFetcher(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
 		return
 	}
+	fetcher.CertExpiresAt = nil
+	fetcher.TenantId = middleware.CurrentTenant(c)
+
+	before, err := h.storage.GetFetcher(c.Request.Context(), fetcher.TenantId, id)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, fetcherResource)
+		return
+	}
+	fetcher.KeepWebhooks(before)
 
 	if err = fetcher.Validate(); err != nil {
 		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
 		return
 	}
+	if err = h.checkDestination(c, fetcher); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+	if fetcher.Auth != nil && !sealSecret(c, h.logger, h.secrets, &fetcher.Auth.Secret) {
+		return
+	}
+
+	fetcher.Id = id
+	h.replace(c, before, fetcher, models.AuditUpdate)
+}
+
+// PatchFetcher applies JSON Merge Patch to stored fetcher and validates the result like UpdateFetcher does.
+// Stored auth secret is kept unless the patch sets a new one.
+func (h *FetcherHandlers) PatchFetcher(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - fetcher id"})
+		return
+	}
 
-	jobId, err := h.storage.GetFetcherJob(id)
+	if contentType := c.ContentType(); contentType != mergePatchContentType && contentType != gin.MIMEJSON {
+		c.JSON(http.StatusUnsupportedMediaType, models.Response{Error: "content type must be " + mergePatchContentType})
+		return
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
+		return
+	}
+
+	tenantId := middleware.CurrentTenant(c)
+	before, err := h.storage.GetFetcher(c.Request.Context(), tenantId, id)
 	if err != nil {
 		handlePostgresError(c, h.logger, err, fetcherResource)
 		return
 	}
 
-	err = h.worker.UpdateJob(fetcher, jobId)
+	doc, err := json.Marshal(before)
 	if err != nil {
-		h.logger.Printf("Job update err: %s", err)
-		c.JSON(http.StatusInternalServerError, models.Response{Error: registerJobErr})
+		requestLogger(c, h.logger).WithError(err).Error("Fetcher marshal error")
+		c.JSON(http.StatusInternalServerError, models.Response{Error: "couldn't patch fetcher"})
 		return
 	}
+	merged, err := mergePatch(doc, patch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
+		return
+	}
+	fetcher := &models.Fetcher{}
+	if err = json.Unmarshal(merged, fetcher); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
+		return
+	}
+	fetcher.Id = id
+	fetcher.TenantId = tenantId
+	fetcher.CertExpiresAt = nil
+	fetcher.CertWarning = false
+	fetcher.KeepWebhooks(before)
 
+	if err = fetcher.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+	if err = h.checkDestination(c, fetcher); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+	if fetcher.Auth != nil && !sealSecret(c, h.logger, h.secrets, &fetcher.Auth.Secret) {
+		return
+	}
+
+	h.replace(c, before, fetcher, models.AuditUpdate)
+}
+
+// replace stores fetcher as the next revision of before. Then job of fetcher which isn't paused is rescheduled
+// when interval changes, otherwise it just gets the new definition.
+func (h *FetcherHandlers) replace(c *gin.Context, before, fetcher *models.Fetcher, action string) {
+	tenant, err := h.storage.GetTenant(c.Request.Context(), fetcher.TenantId)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, tenantResource)
+		return
+	}
+	if err = tenant.CheckInterval(fetcher.Interval); err != nil {
+		c.JSON(http.StatusForbidden, models.Response{Error: err.Error()})
+		return
+	}
+
+	fetcher.Paused = before.Paused
+	fetcher.Revision = before.Revision + 1
+	fetcher.JobId = before.JobId
+
+	// job is touched only once fetcher is stored, so failed update leaves it running the old definition
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.UpdateFetcher(c.Request.Context(), fetcher); err != nil {
+			return nil, err
+		}
+		fetcher.TakeSecret()
+
+		return &models.AuditEntry{
+			Action:     action,
+			Resource:   fetcherResource,
+			ResourceId: strconv.Itoa(fetcher.Id),
+			FetcherId:  &fetcher.Id,
+			Before:     *before,
+			After:      *fetcher,
+		}, nil
+	})
+	if isUniqueViolation(err, fetcherNameIndex) {
+		c.JSON(http.StatusBadRequest, models.Response{Error: fetcherNameErr})
+		return
+	}
+	// another update stored the same revision first
+	if isUniqueViolation(err, fetcherRevisionIndex) {
+		c.JSON(http.StatusConflict, models.Response{Error: revisionErr})
+		return
+	}
+	if err != nil {
+		handlePostgresError(c, h.logger, err, fetcherResource)
+		return
+	}
+
+	if !fetcher.Paused {
+		if fetcher.Interval != before.Interval {
+			err = h.worker.UpdateJob(fetcher, before.JobId)
+		} else {
+			err = h.worker.RefreshJob(fetcher)
+		}
+		if err != nil {
+			requestLogger(c, h.logger).WithError(err).Error("Job update error")
+			c.JSON(http.StatusInternalServerError, models.Response{Error: registerJobErr})
+			return
+		}
+
+		if fetcher.JobId != before.JobId {
+			err = h.storage.UpdateFetcherJobId(c.Request.Context(), fetcher.Id, fetcher.JobId)
+			if err != nil {
+				handlePostgresError(c, h.logger, err, fetcherResource)
+				return
+			}
+		}
+	}
+
+	fetcher.RedactWebhooks()
+	c.JSON(http.StatusOK, fetcher)
+}
+
+func (h *FetcherHandlers) GetRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - fetcher id"})
+		return
+	}
+
+	revisions, err := h.storage.GetFetcherRevisions(c.Request.Context(), middleware.CurrentTenant(c), id)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, fetcherResource)
+		return
+	}
+
+	for i := range revisions {
+		revisions[i].Definition.RedactWebhooks()
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// RestoreRevision stores definition from given revision as the next revision of fetcher
+func (h *FetcherHandlers) RestoreRevision(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - fetcher id"})
+		return
+	}
+	revision, err := strconv.Atoi(c.Param(revisionKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - revision"})
+		return
+	}
+
+	tenantId := middleware.CurrentTenant(c)
+	before, err := h.storage.GetFetcher(c.Request.Context(), tenantId, id)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, fetcherResource)
+		return
+	}
+
+	rev, err := h.storage.GetFetcherRevision(c.Request.Context(), tenantId, id, revision)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, revisionResource)
+		return
+	}
+
+	fetcher := &rev.Definition
 	fetcher.Id = id
-	err = h.storage.UpdateFetcher(fetcher)
+	fetcher.TenantId = tenantId
+	fetcher.CertExpiresAt = nil
+	fetcher.RestoreWebhooks(before)
+
+	// stored auth secret is kept by restore, so it has to be there and belong to auth of the same type
+	if fetcher.Auth != nil {
+		secret, err := h.storage.GetFetcherSecret(c.Request.Context(), id)
+		if err != nil {
+			handlePostgresError(c, h.logger, err, fetcherResource)
+			return
+		}
+		if len(secret) == 0 || before.Auth == nil || before.Auth.Type != fetcher.Auth.Type {
+			c.JSON(http.StatusBadRequest, models.Response{Error: authSecretErr})
+			return
+		}
+	}
+
+	// validation and destination policy might have changed since revision was stored
+	if err = fetcher.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+	if err = h.checkDestination(c, fetcher); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+
+	h.replace(c, before, fetcher, models.AuditRestore)
+}
+
+// PauseFetcher removes job of fetcher, so it isn't run until it's resumed
+func (h *FetcherHandlers) PauseFetcher(c *gin.Context) {
+	h.setPaused(c, true)
+}
+
+func (h *FetcherHandlers) ResumeFetcher(c *gin.Context) {
+	h.setPaused(c, false)
+}
+
+func (h *FetcherHandlers) setPaused(c *gin.Context, paused bool) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - fetcher id"})
+		return
+	}
+
+	fetcher, err := h.storage.GetFetcher(c.Request.Context(), middleware.CurrentTenant(c), id)
 	if err != nil {
 		handlePostgresError(c, h.logger, err, fetcherResource)
 		return
 	}
+	if err = h.changePaused(c, fetcher, paused); err != nil {
+		h.handleOperationError(c, err)
+		return
+	}
+	fetcher.RedactWebhooks()
 
 	c.JSON(http.StatusOK, fetcher)
 }
 
+// changePaused pauses or resumes fetcher unless it's already in that state. Job of paused fetcher is removed once
+// the change is stored, job of resumed one is registered first, as its id is stored, and removed when storing fails.
+func (h *FetcherHandlers) changePaused(c *gin.Context, fetcher *models.Fetcher, paused bool) error {
+	if fetcher.Paused == paused {
+		return nil
+	}
+	before := *fetcher
+
+	action := models.AuditResume
+	if paused {
+		fetcher.JobId = 0
+		action = models.AuditPause
+	} else if err := h.worker.RegisterJob(fetcher); err != nil {
+		requestLogger(c, h.logger).WithError(err).Error("Job registration error")
+		return errRegisterJob
+	}
+	fetcher.Paused = paused
+
+	err := audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		err := s.SetFetcherPaused(c.Request.Context(), fetcher.TenantId, fetcher.Id, paused, fetcher.JobId)
+		if err != nil {
+			return nil, err
+		}
+
+		id := fetcher.Id
+		return &models.AuditEntry{
+			Action:     action,
+			Resource:   fetcherResource,
+			ResourceId: strconv.Itoa(id),
+			FetcherId:  &id,
+			Before:     before,
+			After:      *fetcher,
+		}, nil
+	})
+	if err != nil {
+		if !paused {
+			h.worker.DeregisterJob(fetcher.JobId)
+		}
+		*fetcher = before
+		return err
+	}
+
+	if paused {
+		h.worker.DeregisterJob(before.JobId)
+	}
+	return nil
+}
+
 func (h *FetcherHandlers) GetHistory(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param(idKey))
 	if err != nil {
@@ -149,7 +674,7 @@ func (h *FetcherHandlers) GetHistory(c *gin.Context) {
 		return
 	}
 
-	history, err := h.storage.GetHistory(id)
+	history, err := h.storage.GetHistory(c.Request.Context(), middleware.CurrentTenant(c), id)
 	if err != nil {
 		handlePostgresError(c, h.logger, err, "fetcher history")
 		return
@@ -157,3 +682,47 @@ func (h *FetcherHandlers) GetHistory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, history)
 }
+
+func (h *FetcherHandlers) GetStats(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - fetcher id"})
+		return
+	}
+
+	window := defaultStatsWindow
+	if param, ok := c.GetQuery(windowKey); ok {
+		window, err = time.ParseDuration(param)
+		if err != nil || window <= 0 {
+			c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - window"})
+			return
+		}
+	}
+
+	stats, err := h.storage.GetStats(c.Request.Context(), middleware.CurrentTenant(c), id, time.Now().Add(-window).Unix())
+	if err != nil {
+		handlePostgresError(c, h.logger, err, "fetcher stats")
+		return
+	}
+	stats.Window = window.String()
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// checkDestination rejects fetcher whose url, proxy or chat webhook urls point to destination blocked by policy
+func (h *FetcherHandlers) checkDestination(c *gin.Context, fetcher *models.Fetcher) error {
+	urls := []string{fetcher.Url, fetcher.SlackWebhookUrl, fetcher.TeamsWebhookUrl}
+	if fetcher.Proxy != models.ProxyDirect {
+		urls = append(urls, fetcher.Proxy)
+	}
+	for _, u := range urls {
+		if len(u) == 0 {
+			continue
+		}
+		if err := h.policy.CheckUrl(c.Request.Context(), u); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
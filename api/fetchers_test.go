@@ -4,23 +4,27 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"log"
+	"math"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/destination"
 	"github.com/BarTar213/bartlomiej-tarczynski/mock"
 	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/notifier"
 	"github.com/BarTar213/bartlomiej-tarczynski/storage"
 	"github.com/BarTar213/bartlomiej-tarczynski/worker"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
-var logger = log.New(os.Stdout, "", log.LstdFlags)
+var logger = logrus.New()
 
 const (
 	validId     = "5"
@@ -32,7 +36,7 @@ const (
 func TestFetcherHandlers_AddFetcher(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
-		logger  *log.Logger
+		logger  *logrus.Logger
 		conf    *config.Config
 	}
 	tests := []struct {
@@ -133,6 +137,23 @@ func TestFetcherHandlers_AddFetcher(t *testing.T) {
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "negative_add_fetcher_quota_error",
+			fields: fields{
+				storage: &mock.Storage{
+					AddFetcherQuotaErr: true,
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			body: &models.Fetcher{
+				Url:      exampleUrl,
+				Interval: 60,
+			},
+			wantStatus: http.StatusForbidden,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -159,7 +180,7 @@ func TestFetcherHandlers_AddFetcher(t *testing.T) {
 func TestFetcherHandlers_DeleteFetcher(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
-		logger  *log.Logger
+		logger  *logrus.Logger
 		conf    *config.Config
 	}
 	tests := []struct {
@@ -230,7 +251,7 @@ func TestFetcherHandlers_DeleteFetcher(t *testing.T) {
 func TestFetcherHandlers_GetFetchers(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
-		logger  *log.Logger
+		logger  *logrus.Logger
 		conf    *config.Config
 	}
 	tests := []struct {
@@ -283,10 +304,139 @@ func TestFetcherHandlers_GetFetchers(t *testing.T) {
 	}
 }
 
+func TestFetcherHandlers_GetFetchers_Filter(t *testing.T) {
+	fetchers := []models.Fetcher{
+		{Id: 1, TenantId: 1, Name: "payments-api", Url: exampleUrl, Interval: 60, Labels: map[string]string{"env": "prod"}},
+		{Id: 2, TenantId: 1, Name: "payments-web", Url: "https://example.com/web", Interval: 10, Labels: map[string]string{"env": "prod", "legacy": "true"}, Paused: true},
+		{Id: 3, TenantId: 1, Name: "search", Url: exampleUrl, Interval: 30, Labels: map[string]string{"env": "staging"}},
+	}
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCount  int
+		wantTotal  string
+		wantNext   bool
+	}{
+		{
+			name:       "positive_get_fetchers_first_page",
+			query:      "limit=2&sort=-id",
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+			wantTotal:  "3",
+			wantNext:   true,
+		},
+		{
+			name:       "positive_get_fetchers_all_filters",
+			query:      "url=httpbin&host=httpbin.org&selector=env=prod&enabled=true&last_status=failed&interval_min=10&interval_max=60&sort=url",
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+			wantTotal:  "1",
+		},
+		{
+			name:       "positive_get_fetchers_disabled",
+			query:      "enabled=false",
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+			wantTotal:  "1",
+		},
+		{
+			name:       "positive_get_fetchers_nothing_matches",
+			query:      "host=example.org",
+			wantStatus: http.StatusOK,
+			wantCount:  0,
+			wantTotal:  "0",
+		},
+		{
+			name:       "negative_get_fetchers_invalid_enabled_error",
+			query:      "enabled=maybe",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_fetchers_invalid_interval_error",
+			query:      "interval_min=ten",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_fetchers_invalid_interval_range_error",
+			query:      "interval_min=60&interval_max=10",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_fetchers_invalid_sort_error",
+			query:      "sort=job_id",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_fetchers_invalid_last_status_error",
+			query:      "last_status=broken",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_fetchers_invalid_limit_error",
+			query:      "limit=0",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "positive_get_fetchers_by_name_and_selector",
+			query:      "name=PAYMENTS&selector=env=prod,!legacy&sort=-name",
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+			wantTotal:  "1",
+		},
+		{
+			name:       "negative_get_fetchers_invalid_selector_error",
+			query:      "selector=-env",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_fetchers_invalid_cursor_error",
+			query:      "cursor=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(&mock.Storage{Fetchers: fetchers}),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/api/fetcher?"+tt.query, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			got := make([]models.Fetcher, 0)
+			_ = json.Unmarshal(w.Body.Bytes(), &got)
+			if len(got) != tt.wantCount {
+				t.Errorf("Expected %d fetchers, got: %d", tt.wantCount, len(got))
+			}
+			if total := w.Header().Get("X-Total-Count"); total != tt.wantTotal {
+				t.Errorf("Expected X-Total-Count %s, got: %q", tt.wantTotal, total)
+			}
+			link := w.Header().Get("Link")
+			if strings.Contains(link, `rel="next"`) != tt.wantNext {
+				t.Errorf("Expected next link %v, got: %q", tt.wantNext, link)
+			}
+			if tt.wantNext && !strings.Contains(link, "cursor=") {
+				t.Errorf("Expected cursor in next link, got: %q", link)
+			}
+		})
+	}
+}
+
 func TestFetcherHandlers_GetHistory(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
-		logger  *log.Logger
+		logger  *logrus.Logger
 		conf    *config.Config
 	}
 	tests := []struct {
@@ -356,11 +506,12 @@ func TestFetcherHandlers_GetHistory(t *testing.T) {
 
 func TestNewFetcherHandlers(t *testing.T) {
 	type args struct {
-		storage storage.Storage
-		logger  *log.Logger
-		worker  *worker.Worker
-		pool    *sync.Pool
-		conf    *config.Config
+		storage    storage.Storage
+		logger     *logrus.Logger
+		worker     *worker.Worker
+		dispatcher *notifier.Dispatcher
+		pool       *sync.Pool
+		conf       *config.Config
 	}
 	tests := []struct {
 		name string
@@ -382,7 +533,7 @@ func TestNewFetcherHandlers(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewFetcherHandlers(tt.args.storage, tt.args.worker, tt.args.pool, tt.args.logger); !reflect.DeepEqual(got, tt.want) {
+			if got := NewFetcherHandlers(tt.args.storage, tt.args.worker, tt.args.dispatcher, tt.args.pool, nil, nil, 0, tt.args.logger); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewFetcherHandlers() = %v, want %v", got, tt.want)
 			}
 		})
@@ -392,7 +543,7 @@ func TestNewFetcherHandlers(t *testing.T) {
 func TestFetcherHandlers_UpdateFetcher(t *testing.T) {
 	type fields struct {
 		storage storage.Storage
-		logger  *log.Logger
+		logger  *logrus.Logger
 		conf    *config.Config
 	}
 	tests := []struct {
@@ -481,10 +632,28 @@ func TestFetcherHandlers_UpdateFetcher(t *testing.T) {
 			wantStatus: http.StatusBadRequest,
 		},
 		{
-			name: "negative_update_fetcher_get_fetcher_job_error",
+			name: "negative_update_fetcher_get_fetcher_error",
+			fields: fields{
+				storage: &mock.Storage{
+					GetFetcherErr: true,
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			body: &models.Fetcher{
+				Url:      exampleUrl,
+				Interval: 60,
+			},
+			fetcherId:  validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "negative_update_fetcher_get_tenant_error",
 			fields: fields{
 				storage: &mock.Storage{
-					GetFetcherJobErr: true,
+					GetTenantErr: true,
 				},
 				logger: logger,
 				conf: &config.Config{
@@ -498,6 +667,24 @@ func TestFetcherHandlers_UpdateFetcher(t *testing.T) {
 			fetcherId:  validId,
 			wantStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "negative_update_fetcher_min_interval_quota_error",
+			fields: fields{
+				storage: &mock.Storage{
+					Tenant: &models.Tenant{Id: models.DefaultTenantId, MinInterval: 300},
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			body: &models.Fetcher{
+				Url:      exampleUrl,
+				Interval: 60,
+			},
+			fetcherId:  validId,
+			wantStatus: http.StatusForbidden,
+		},
 		{
 			name: "negative_update_fetcher_storage_error",
 			fields: fields{
@@ -539,8 +726,827 @@ func TestFetcherHandlers_UpdateFetcher(t *testing.T) {
 	}
 }
 
-func checkResponseStatusCode(t *testing.T, want int, got int) {
-	if want != got {
-		t.Errorf("Expected response status code: %d, got: %d", want, got)
+func TestFetcherHandlers_GetStats(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		logger  *logrus.Logger
+		conf    *config.Config
+	}
+	failed := "timeout"
+	now := time.Now().Unix()
+	history := []models.History{
+		{FetcherId: 5, CreatedAt: now, Duration: 1},
+		{FetcherId: 5, CreatedAt: now - 60, Duration: 5, Error: &failed},
+		{FetcherId: 5, CreatedAt: now - 120, Duration: 2},
+		{FetcherId: 5, CreatedAt: now - 180, Duration: 3},
+		// outside of the window and of other fetcher
+		{FetcherId: 5, CreatedAt: now - 7200, Duration: 50, Error: &failed},
+		{FetcherId: 6, CreatedAt: now, Duration: 40},
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		fetcherId  string
+		query      string
+		wantStatus int
+		wantStats  *models.Stats
+	}{
+		{
+			name: "positive_get_stats",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  validId,
+			query:      "window=1h",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "positive_get_stats_from_history",
+			fields: fields{
+				storage: &mock.Storage{History: history},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  validId,
+			query:      "window=1h",
+			wantStatus: http.StatusOK,
+			wantStats: &models.Stats{
+				FetcherId:    5,
+				Window:       "1h0m0s",
+				Runs:         4,
+				Successes:    3,
+				SuccessRatio: 0.75,
+				Errors:       map[string]int{failed: 1},
+				Latency:      models.Latency{P50: 2.5, P90: 4.4, P99: 4.94, Max: 5},
+			},
+		},
+		{
+			name: "positive_get_stats_default_window",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_stats_invalid_id_param_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_stats_invalid_window_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  validId,
+			query:      "window=-5m",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_stats_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					GetStatsErr: true,
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			fetcherId:  validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/api/fetcher/%s/stats?%s", tt.fetcherId, tt.query)
+			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+			if tt.wantStats == nil {
+				return
+			}
+
+			stats := &models.Stats{}
+			if err := json.Unmarshal(w.Body.Bytes(), stats); err != nil {
+				t.Fatalf("Unexpected response body: %s", w.Body.String())
+			}
+			stats.Latency = roundLatency(stats.Latency)
+			if !reflect.DeepEqual(stats, tt.wantStats) {
+				t.Errorf("Expected stats %+v, got: %+v", tt.wantStats, stats)
+			}
+		})
+	}
+}
+
+// roundLatency drops float noise of interpolated percentiles
+func roundLatency(latency models.Latency) models.Latency {
+	round := func(v float64) float64 {
+		return math.Round(v*1000) / 1000
+	}
+	return models.Latency{P50: round(latency.P50), P90: round(latency.P90), P99: round(latency.P99), Max: round(latency.Max)}
+}
+
+func TestFetcherHandlers_PauseFetcher(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		action     string
+		fetcherId  string
+		wantStatus int
+		wantPaused bool
+		wantAudit  int
+		wantJobs   int
+	}{
+		{
+			name:       "positive_pause_fetcher",
+			storage:    &mock.Storage{},
+			action:     "pause",
+			fetcherId:  validId,
+			wantStatus: http.StatusOK,
+			wantPaused: true,
+			wantAudit:  1,
+		},
+		{
+			name:       "positive_resume_fetcher",
+			storage:    &mock.Storage{Fetcher: &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60, Paused: true}},
+			action:     "resume",
+			fetcherId:  validId,
+			wantStatus: http.StatusOK,
+			wantPaused: false,
+			wantAudit:  1,
+		},
+		{
+			name:       "positive_pause_paused_fetcher",
+			storage:    &mock.Storage{Fetcher: &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60, Paused: true}},
+			action:     "pause",
+			fetcherId:  validId,
+			wantStatus: http.StatusOK,
+			wantPaused: true,
+			wantAudit:  0,
+		},
+		{
+			name:       "negative_pause_fetcher_invalid_id_error",
+			storage:    &mock.Storage{},
+			action:     "pause",
+			fetcherId:  invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_pause_fetcher_get_fetcher_error",
+			storage:    &mock.Storage{GetFetcherErr: true},
+			action:     "pause",
+			fetcherId:  validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "negative_resume_fetcher_storage_error",
+			storage:    &mock.Storage{Fetcher: &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60, Paused: true}, SetFetcherPausedErr: true},
+			action:     "resume",
+			fetcherId:  validId,
+			wantStatus: http.StatusInternalServerError,
+			wantJobs:   0,
+		},
+		{
+			name:       "negative_pause_fetcher_storage_error",
+			storage:    &mock.Storage{Fetcher: &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60}, SetFetcherPausedErr: true},
+			action:     "pause",
+			fetcherId:  validId,
+			wantStatus: http.StatusInternalServerError,
+			wantJobs:   1,
+		},
+		{
+			name:       "negative_resume_fetcher_audit_error",
+			storage:    &mock.Storage{Fetcher: &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60, Paused: true}, AddAuditEntryErr: true},
+			action:     "resume",
+			fetcherId:  validId,
+			wantStatus: http.StatusInternalServerError,
+			wantJobs:   0,
+		},
+		{
+			name:       "negative_pause_fetcher_audit_error",
+			storage:    &mock.Storage{Fetcher: &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60}, AddAuditEntryErr: true},
+			action:     "pause",
+			fetcherId:  validId,
+			wantStatus: http.StatusInternalServerError,
+			wantJobs:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(tt.storage),
+				WithWorker(),
+			)
+			defer a.Worker.Stop()
+			// running fetcher has its job registered
+			if fetcher := tt.storage.Fetcher; fetcher != nil && !fetcher.Paused {
+				if err := a.Worker.RegisterJob(fetcher); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/api/fetcher/%s/%s", tt.fetcherId, tt.action)
+			req, _ := http.NewRequest(http.MethodPost, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				if got := a.Worker.Jobs(); got != tt.wantJobs {
+					t.Errorf("Expected %d jobs, got: %d", tt.wantJobs, got)
+				}
+				return
+			}
+
+			fetcher := &models.Fetcher{}
+			_ = json.Unmarshal(w.Body.Bytes(), fetcher)
+			if fetcher.Paused != tt.wantPaused {
+				t.Errorf("Expected paused %v, got: %v", tt.wantPaused, fetcher.Paused)
+			}
+			if len(tt.storage.AuditLog) != tt.wantAudit {
+				t.Errorf("Expected %d audit entries, got: %d", tt.wantAudit, len(tt.storage.AuditLog))
+			}
+		})
+	}
+}
+
+func TestFetcherHandlers_GetRevisions(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		fetcherId  string
+		wantStatus int
+	}{
+		{
+			name:       "positive_get_revisions",
+			storage:    &mock.Storage{},
+			fetcherId:  validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_get_revisions_invalid_id_error",
+			storage:    &mock.Storage{},
+			fetcherId:  invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_revisions_storage_error",
+			storage:    &mock.Storage{GetFetcherRevisionsErr: true},
+			fetcherId:  validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(tt.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/api/fetcher/%s/revisions", tt.fetcherId)
+			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestFetcherHandlers_RestoreRevision(t *testing.T) {
+	current := func() *models.Fetcher {
+		return &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl + "/current", Interval: 30, Revision: 2}
+	}
+	revisions := []models.FetcherRevision{
+		{FetcherId: 5, Revision: 1, Definition: models.Fetcher{Url: exampleUrl, Interval: 60, Revision: 1}},
+		{FetcherId: 5, Revision: 2, Definition: models.Fetcher{Interval: 60, Revision: 2}},
+		{FetcherId: 5, Revision: 3, Definition: models.Fetcher{Url: exampleUrl, Interval: 60, Revision: 3, Auth: &models.Auth{Type: models.AuthBasic, Username: "user"}}},
+		{FetcherId: 5, Revision: 4, Definition: models.Fetcher{Url: exampleUrl, Interval: 60, Revision: 4, SlackWebhookUrl: "https://hooks.slack.com/xxxxx"}},
+	}
+	withAuth := func(authType string) *models.Fetcher {
+		fetcher := current()
+		fetcher.Auth = &models.Auth{Type: authType, Username: "user"}
+		return fetcher
+	}
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		fetcherId  string
+		revision   string
+		wantStatus int
+	}{
+		{
+			name:       "positive_restore_revision",
+			storage:    &mock.Storage{Fetcher: current(), Revisions: revisions},
+			fetcherId:  validId,
+			revision:   "1",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_restore_revision_invalid_id_error",
+			storage:    &mock.Storage{Fetcher: current(), Revisions: revisions},
+			fetcherId:  invalidId,
+			revision:   "1",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_restore_revision_invalid_revision_error",
+			storage:    &mock.Storage{Fetcher: current(), Revisions: revisions},
+			fetcherId:  validId,
+			revision:   "first",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_restore_missing_revision_error",
+			storage:    &mock.Storage{Fetcher: current(), Revisions: revisions},
+			fetcherId:  validId,
+			revision:   "9",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_restore_invalid_revision_definition_error",
+			storage:    &mock.Storage{Fetcher: current(), Revisions: revisions},
+			fetcherId:  validId,
+			revision:   "2",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_restore_revision_storage_error",
+			storage:    &mock.Storage{Fetcher: current(), GetFetcherRevisionErr: true},
+			fetcherId:  validId,
+			revision:   "1",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "negative_restore_revision_update_error",
+			storage:    &mock.Storage{Fetcher: current(), Revisions: revisions, UpdateFetcherErr: true},
+			fetcherId:  validId,
+			revision:   "1",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "negative_restore_revision_conflict_error",
+			storage:    &mock.Storage{Fetcher: current(), Revisions: revisions, UpdateFetcherConflictErr: true},
+			fetcherId:  validId,
+			revision:   "1",
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "positive_restore_revision_auth_with_stored_secret",
+			storage:    &mock.Storage{Fetcher: withAuth(models.AuthBasic), Secret: "sealed", Revisions: revisions},
+			fetcherId:  validId,
+			revision:   "3",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_restore_revision_auth_without_secret_error",
+			storage:    &mock.Storage{Fetcher: current(), Revisions: revisions},
+			fetcherId:  validId,
+			revision:   "3",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_restore_revision_auth_of_other_type_error",
+			storage:    &mock.Storage{Fetcher: withAuth(models.AuthBearer), Secret: "sealed", Revisions: revisions},
+			fetcherId:  validId,
+			revision:   "3",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "positive_restore_revision_removed_webhook",
+			storage:    &mock.Storage{Fetcher: current(), Revisions: revisions},
+			fetcherId:  validId,
+			revision:   "4",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(tt.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/api/fetcher/%s/revisions/%s/restore", tt.fetcherId, tt.revision)
+			req, _ := http.NewRequest(http.MethodPost, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			fetcher := &models.Fetcher{}
+			_ = json.Unmarshal(w.Body.Bytes(), fetcher)
+			if fetcher.Url != exampleUrl || fetcher.Interval != 60 || fetcher.Revision != 3 || len(fetcher.SlackWebhookUrl) > 0 {
+				t.Errorf("Expected revision %s restored as revision 3 with current webhooks, got: %+v", tt.revision, fetcher)
+			}
+		})
+	}
+}
+
+func TestFetcherHandlers_GetFetcher(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		fetcherId  string
+		wantStatus int
+	}{
+		{
+			name:       "positive_get_fetcher",
+			storage:    &mock.Storage{},
+			fetcherId:  validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_get_fetcher_invalid_id_error",
+			storage:    &mock.Storage{},
+			fetcherId:  invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_get_fetcher_storage_error",
+			storage:    &mock.Storage{GetFetcherErr: true},
+			fetcherId:  validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(tt.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/fetcher/%s", tt.fetcherId), nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestFetcherHandlers_PatchFetcher(t *testing.T) {
+	current := func() *models.Fetcher {
+		return &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60, Emails: []string{"a@example.com"}, Revision: 1}
+	}
+	tests := []struct {
+		name        string
+		storage     *mock.Storage
+		fetcherId   string
+		contentType string
+		body        string
+		wantStatus  int
+		want        *models.Fetcher
+	}{
+		{
+			name:        "positive_patch_fetcher_emails",
+			storage:     &mock.Storage{Fetcher: current()},
+			fetcherId:   validId,
+			contentType: mergePatchContentType,
+			body:        `{"emails":["b@example.com"]}`,
+			wantStatus:  http.StatusOK,
+			want:        &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60, Emails: []string{"b@example.com"}, Revision: 2},
+		},
+		{
+			name:        "positive_patch_fetcher_interval_and_remove_emails",
+			storage:     &mock.Storage{Fetcher: current()},
+			fetcherId:   validId,
+			contentType: gin.MIMEJSON,
+			body:        `{"interval":30,"emails":null}`,
+			wantStatus:  http.StatusOK,
+			want:        &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 30, Revision: 2},
+		},
+		{
+			name:        "positive_patch_fetcher_ignores_read_only_fields",
+			storage:     &mock.Storage{Fetcher: current()},
+			fetcherId:   validId,
+			contentType: mergePatchContentType,
+			body:        `{"id":7,"tenant_id":2,"paused":true,"revision":9}`,
+			wantStatus:  http.StatusOK,
+			want:        &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60, Emails: []string{"a@example.com"}, Revision: 2},
+		},
+		{
+			name:        "negative_patch_fetcher_invalid_id_error",
+			storage:     &mock.Storage{Fetcher: current()},
+			fetcherId:   invalidId,
+			contentType: mergePatchContentType,
+			body:        `{"interval":30}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "negative_patch_fetcher_content_type_error",
+			storage:     &mock.Storage{Fetcher: current()},
+			fetcherId:   validId,
+			contentType: "text/plain",
+			body:        `{"interval":30}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "negative_patch_fetcher_invalid_body_error",
+			storage:     &mock.Storage{Fetcher: current()},
+			fetcherId:   validId,
+			contentType: mergePatchContentType,
+			body:        `{"interval":`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "negative_patch_fetcher_invalid_type_error",
+			storage:     &mock.Storage{Fetcher: current()},
+			fetcherId:   validId,
+			contentType: mergePatchContentType,
+			body:        `{"interval":"30"}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "negative_patch_fetcher_validation_error",
+			storage:     &mock.Storage{Fetcher: current()},
+			fetcherId:   validId,
+			contentType: mergePatchContentType,
+			body:        `{"url":null}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "negative_patch_fetcher_get_fetcher_error",
+			storage:     &mock.Storage{GetFetcherErr: true},
+			fetcherId:   validId,
+			contentType: mergePatchContentType,
+			body:        `{"interval":30}`,
+			wantStatus:  http.StatusInternalServerError,
+		},
+		{
+			name:        "negative_patch_fetcher_update_error",
+			storage:     &mock.Storage{Fetcher: current(), UpdateFetcherErr: true},
+			fetcherId:   validId,
+			contentType: mergePatchContentType,
+			body:        `{"interval":30}`,
+			wantStatus:  http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(tt.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/api/fetcher/%s", tt.fetcherId)
+			req, _ := http.NewRequest(http.MethodPatch, reqUrl, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+			if tt.want == nil {
+				return
+			}
+
+			got := &models.Fetcher{}
+			_ = json.Unmarshal(w.Body.Bytes(), got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected patched fetcher %+v, got: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func checkResponseStatusCode(t *testing.T, want int, got int) {
+	if want != got {
+		t.Errorf("Expected response status code: %d, got: %d", want, got)
+	}
+}
+
+func TestFetcherHandlers_UpdateFetcher_HidesSecret(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	a := NewApi(
+		WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+		WithLogger(logger),
+		WithStorage(&mock.Storage{}),
+		WithSecrets(testKeyring),
+		WithWorker(),
+	)
+
+	jsonBody, _ := json.Marshal(&models.Fetcher{
+		Url:      exampleUrl,
+		Interval: 60,
+		Auth:     &models.Auth{Type: models.AuthBasic, Username: "user", Secret: "s3cret"},
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/api/fetcher/%s", validId), bytes.NewBuffer(jsonBody))
+	a.Router.ServeHTTP(w, req)
+	checkResponseStatusCode(t, http.StatusOK, w.Code)
+
+	fetcher := &models.Fetcher{}
+	_ = json.Unmarshal(w.Body.Bytes(), fetcher)
+	if fetcher.Auth == nil || fetcher.Auth.Username != "user" {
+		t.Fatalf("Expected auth in response, got: %s", w.Body.String())
+	}
+	if len(fetcher.Auth.Secret) > 0 || bytes.Contains(w.Body.Bytes(), []byte("s3cret")) {
+		t.Errorf("Expected secret to be hidden, got: %s", w.Body.String())
+	}
+}
+
+func TestFetcherHandlers_PatchFetcher_JobAfterStore(t *testing.T) {
+	tests := []struct {
+		name        string
+		storage     *mock.Storage
+		wantStatus  int
+		wantEntries float64
+	}{
+		{
+			name:        "positive_patch_fetcher_reschedules_job",
+			storage:     &mock.Storage{Fetcher: &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60}},
+			wantStatus:  http.StatusOK,
+			wantEntries: 1,
+		},
+		{
+			name:        "negative_patch_fetcher_update_error_keeps_job",
+			storage:     &mock.Storage{Fetcher: &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60}, UpdateFetcherErr: true},
+			wantStatus:  http.StatusInternalServerError,
+			wantEntries: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(tt.storage),
+				WithWorker(),
+			)
+			defer a.Worker.Stop()
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/api/fetcher/%s", validId), strings.NewReader(`{"interval":30}`))
+			req.Header.Set("Content-Type", mergePatchContentType)
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+
+			families, _ := a.Metrics.Registry.Gather()
+			for _, family := range families {
+				if strings.HasSuffix(family.GetName(), "cron_entries") {
+					if got := family.GetMetric()[0].GetGauge().GetValue(); got != tt.wantEntries {
+						t.Errorf("Expected %v scheduled jobs, got: %v", tt.wantEntries, got)
+					}
+					return
+				}
+			}
+			t.Error("Expected cron entries metric")
+		})
+	}
+}
+
+func TestFetcherHandlers_RedactsWebhooks(t *testing.T) {
+	slackUrl := "https://hooks.slack.com/services/T0/B0/s3cret"
+	s := &mock.Storage{Fetcher: &models.Fetcher{Id: 5, TenantId: 1, Url: exampleUrl, Interval: 60, SlackWebhookUrl: slackUrl}}
+	gin.SetMode(gin.ReleaseMode)
+	a := NewApi(
+		WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+		WithLogger(logger),
+		WithStorage(s),
+		WithWorker(),
+	)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/fetcher/%s", validId), nil)
+	a.Router.ServeHTTP(w, req)
+	checkResponseStatusCode(t, http.StatusOK, w.Code)
+	if bytes.Contains(w.Body.Bytes(), []byte("s3cret")) {
+		t.Fatalf("Expected webhook url to be redacted, got: %s", w.Body.String())
+	}
+
+	// fetcher read from api is sent back with redacted url, which keeps the stored one
+	w2 := httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, fmt.Sprintf("/api/fetcher/%s", validId), bytes.NewReader(w.Body.Bytes()))
+	a.Router.ServeHTTP(w2, req)
+	checkResponseStatusCode(t, http.StatusOK, w2.Code)
+	if bytes.Contains(w2.Body.Bytes(), []byte("s3cret")) {
+		t.Errorf("Expected webhook url to be redacted, got: %s", w2.Body.String())
+	}
+
+	if len(s.AuditLog) != 1 {
+		t.Fatalf("Expected audit entry, got: %d", len(s.AuditLog))
+	}
+	if entry, _ := json.Marshal(s.AuditLog[0]); bytes.Contains(entry, []byte("s3cret")) {
+		t.Errorf("Expected webhook url to be redacted in audit log, got: %s", entry)
+	}
+}
+
+func TestFetcherHandlers_Destination(t *testing.T) {
+	policy, _ := destination.NewPolicy(config.Destinations{})
+	tests := []struct {
+		name       string
+		method     string
+		reqUrl     string
+		body       interface{}
+		wantStatus int
+	}{
+		{
+			name:       "negative_add_fetcher_metadata_address_error",
+			method:     http.MethodPost,
+			reqUrl:     "/api/fetcher",
+			body:       &models.Fetcher{Url: "http://169.254.169.254/latest/meta-data", Interval: 60},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_update_fetcher_private_proxy_error",
+			method:     http.MethodPut,
+			reqUrl:     fmt.Sprintf("/api/fetcher/%s", validId),
+			body:       &models.Fetcher{Url: "http://93.184.216.34", Interval: 60, Proxy: "http://10.0.0.1:3128"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_add_fetcher_private_slack_webhook_error",
+			method:     http.MethodPost,
+			reqUrl:     "/api/fetcher",
+			body:       &models.Fetcher{Url: "http://93.184.216.34", Interval: 60, SlackWebhookUrl: "https://127.0.0.1:8443/hook"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_add_webhook_metadata_address_error",
+			method:     http.MethodPost,
+			reqUrl:     "/api/webhooks",
+			body:       &models.Webhook{Url: "http://169.254.169.254/hook", Secret: "secret", Events: []string{models.EventFetchFailed}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "positive_add_fetcher_public_address",
+			method:     http.MethodPost,
+			reqUrl:     "/api/fetcher",
+			body:       &models.Fetcher{Url: "http://93.184.216.34", Interval: 60},
+			wantStatus: http.StatusCreated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(&mock.Storage{}),
+				WithDestinations(policy),
+				WithWorker(),
+			)
+
+			jsonBody, _ := json.Marshal(tt.body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.reqUrl, bytes.NewBuffer(jsonBody))
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+
+			if tt.wantStatus == http.StatusBadRequest && !bytes.Contains(w.Body.Bytes(), []byte(destination.ErrBlocked.Error())) {
+				t.Errorf("Expected destination error, got: %s", w.Body.String())
+			}
+		})
 	}
 }
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/BarTar213/bartlomiej-tarczynski/worker"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	pingTimeout    = 2 * time.Second
+	unavailableErr = "database unavailable"
+)
+
+type HealthHandlers struct {
+	storage storage.Storage
+	worker  *worker.Worker
+	logger  logrus.FieldLogger
+}
+
+func NewHealthHandlers(s storage.Storage, w *worker.Worker, l logrus.FieldLogger) *HealthHandlers {
+	return &HealthHandlers{
+		storage: s,
+		worker:  w,
+		logger:  l,
+	}
+}
+
+func (h *HealthHandlers) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, models.Health{Status: models.StatusOk})
+}
+
+func (h *HealthHandlers) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), pingTimeout)
+	defer cancel()
+
+	checks := map[string]error{
+		"postgres":  nil,
+		"scheduler": nil,
+		"resync":    nil,
+	}
+	// readiness is public, so connection details stay in logs
+	if err := h.storage.Ping(ctx); err != nil {
+		requestLogger(c, h.logger).WithError(err).Error("Postgres ping error")
+		checks["postgres"] = errors.New(unavailableErr)
+	}
+	if !h.worker.Running() {
+		checks["scheduler"] = errors.New("scheduler is stopped")
+	}
+	if !h.worker.Synced() {
+		checks["resync"] = errors.New("fetchers are not synced yet")
+	}
+
+	health := models.Health{
+		Status: models.StatusOk,
+		Checks: make(map[string]models.Check),
+	}
+	status := http.StatusOK
+	for name, err := range checks {
+		if err != nil {
+			health.Checks[name] = models.Check{Status: models.StatusFail, Error: err.Error()}
+			health.Status = models.StatusFail
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		health.Checks[name] = models.Check{Status: models.StatusOk}
+	}
+
+	c.JSON(status, health)
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/middleware"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthHandlers_Healthz(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	a := NewApi(
+		WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+		WithLogger(logger),
+		WithStorage(&mock.Storage{PingErr: true}),
+		WithWorker(),
+	)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+
+	a.Router.ServeHTTP(w, req)
+	checkResponseStatusCode(t, http.StatusOK, w.Code)
+}
+
+func TestHealthHandlers_Readyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    storage.Storage
+		resync     bool
+		stop       bool
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "positive_ready",
+			storage:    &mock.Storage{},
+			resync:     true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_ready_not_synced",
+			storage:    &mock.Storage{},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "negative_ready_postgres_error",
+			storage:    &mock.Storage{PingErr: true},
+			resync:     true,
+			wantStatus: http.StatusServiceUnavailable,
+			wantError:  unavailableErr,
+		},
+		{
+			name:       "negative_ready_scheduler_stopped",
+			storage:    &mock.Storage{},
+			resync:     true,
+			stop:       true,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(tt.storage),
+				WithWorker(),
+			)
+			if tt.resync {
+				if err := a.Worker.Resync(context.Background()); err != nil {
+					t.Fatalf("Resync() error = %v", err)
+				}
+			}
+			if tt.stop {
+				a.Worker.Stop()
+			}
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+
+			health := models.Health{}
+			if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+				t.Fatalf("Unexpected response body: %s", w.Body.String())
+			}
+			if got := health.Checks["postgres"].Error; got != tt.wantError {
+				t.Errorf("Expected postgres error: %q, got: %q", tt.wantError, got)
+			}
+		})
+	}
+}
+
+func TestApi_RequestId(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestId string
+	}{
+		{
+			name:      "positive_request_id_propagated",
+			requestId: "abc123",
+		},
+		{
+			name: "positive_request_id_generated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithStorage(&mock.Storage{}),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+			if len(tt.requestId) > 0 {
+				req.Header.Set(middleware.RequestIdHeader, tt.requestId)
+			}
+
+			a.Router.ServeHTTP(w, req)
+			got := w.Header().Get(middleware.RequestIdHeader)
+			if len(got) == 0 || (len(tt.requestId) > 0 && got != tt.requestId) {
+				t.Errorf("Expected request id: %q, got: %q", tt.requestId, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestApi_Metrics(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	a := NewApi(
+		WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+		WithLogger(logger),
+		WithStorage(&mock.Storage{}),
+		WithWorker(),
+	)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/fetcher", nil)
+	a.Router.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/metrics", nil)
+	a.Router.ServeHTTP(w, req)
+	checkResponseStatusCode(t, http.StatusOK, w.Code)
+
+	for _, want := range []string{
+		`fetcher_api_requests_total{method="GET",route="/api/fetcher",status="200"} 1`,
+		`fetcher_cron_entries 0`,
+		`fetcher_jobs_in_flight 0`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("Expected metrics to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "positive_replace_member",
+			doc:   `{"a":"b"}`,
+			patch: `{"a":"c"}`,
+			want:  `{"a":"c"}`,
+		},
+		{
+			name:  "positive_add_member",
+			doc:   `{"a":"b"}`,
+			patch: `{"b":"c"}`,
+			want:  `{"a":"b","b":"c"}`,
+		},
+		{
+			name:  "positive_remove_member",
+			doc:   `{"a":"b","b":"c"}`,
+			patch: `{"a":null}`,
+			want:  `{"b":"c"}`,
+		},
+		{
+			name:  "positive_replace_array",
+			doc:   `{"a":["b","c"]}`,
+			patch: `{"a":["d"]}`,
+			want:  `{"a":["d"]}`,
+		},
+		{
+			name:  "positive_merge_nested_object",
+			doc:   `{"a":{"b":"c","d":"e"}}`,
+			patch: `{"a":{"b":null,"f":"g"}}`,
+			want:  `{"a":{"d":"e","f":"g"}}`,
+		},
+		{
+			name:  "positive_object_replaces_scalar",
+			doc:   `{"a":"b"}`,
+			patch: `{"a":{"c":null,"d":"e"}}`,
+			want:  `{"a":{"d":"e"}}`,
+		},
+		{
+			name:  "positive_non_object_patch_replaces_document",
+			doc:   `{"a":"b"}`,
+			patch: `["c"]`,
+			want:  `["c"]`,
+		},
+		{
+			name:    "negative_invalid_patch_error",
+			doc:     `{"a":"b"}`,
+			patch:   `{"a":`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergePatch([]byte(tt.doc), []byte(tt.patch))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mergePatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var gotValue, wantValue interface{}
+			_ = json.Unmarshal(got, &gotValue)
+			_ = json.Unmarshal([]byte(tt.want), &wantValue)
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("mergePatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/middleware"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	nameKey        = "name"
+	secretResource = "secret"
+)
+
+// SecretHandlers manage secret store, secret values are write-only and never returned
+type SecretHandlers struct {
+	storage storage.Storage
+	secrets *secrets.Manager
+	logger  logrus.FieldLogger
+}
+
+func NewSecretHandlers(s storage.Storage, sm *secrets.Manager, l logrus.FieldLogger) *SecretHandlers {
+	return &SecretHandlers{
+		storage: s,
+		secrets: sm,
+		logger:  l,
+	}
+}
+
+func (h *SecretHandlers) GetSecrets(c *gin.Context) {
+	list, err := h.storage.GetSecrets(c.Request.Context(), middleware.CurrentTenant(c))
+	if err != nil {
+		handlePostgresError(c, h.logger, err, secretResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// SetSecret creates secret with name from path or replaces its value
+func (h *SecretHandlers) SetSecret(c *gin.Context) {
+	secret := &models.Secret{}
+	err := c.ShouldBindJSON(secret)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
+		return
+	}
+
+	secret.TenantId = middleware.CurrentTenant(c)
+	secret.Name = c.Param(nameKey)
+	if err = secret.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := h.secrets.In(s).Set(c.Request.Context(), secret); err != nil {
+			return nil, err
+		}
+
+		// stored secret keeps its creation time, so it differs from update time only when value was replaced
+		action := models.AuditUpdate
+		if secret.CreatedAt == secret.UpdatedAt {
+			action = models.AuditCreate
+		}
+		return &models.AuditEntry{
+			Action:     action,
+			Resource:   secretResource,
+			ResourceId: secret.Name,
+			After:      *secret,
+		}, nil
+	})
+	if err != nil {
+		handleSecretError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, secret)
+}
+
+func (h *SecretHandlers) DeleteSecret(c *gin.Context) {
+	err := audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.DeleteSecret(c.Request.Context(), middleware.CurrentTenant(c), c.Param(nameKey)); err != nil {
+			return nil, err
+		}
+		return &models.AuditEntry{
+			Action:     models.AuditDelete,
+			Resource:   secretResource,
+			ResourceId: c.Param(nameKey),
+		}, nil
+	})
+	if err != nil {
+		handlePostgresError(c, h.logger, err, secretResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{})
+}
+
+// RotateSecrets rewraps every stored secret with primary master key, after that older keys can be removed
+func (h *SecretHandlers) RotateSecrets(c *gin.Context) {
+	rotated := 0
+	err := audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		var err error
+		rotated, err = h.secrets.In(s).Rotate(c.Request.Context())
+		if err != nil {
+			return nil, err
+		}
+		return &models.AuditEntry{
+			Action:   models.AuditRotate,
+			Resource: secretResource,
+			After:    models.Rotated{Rotated: rotated},
+		}, nil
+	})
+	if err != nil {
+		handleSecretError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Rotated{Rotated: rotated})
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
+	"github.com/gin-gonic/gin"
+)
+
+const secretName = "api-token"
+
+var testKeyring, _ = secrets.ParseKeyring("test:MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE=")
+
+func newSecretsApi(s *mock.Storage, keyring *secrets.Keyring) *Api {
+	gin.SetMode(gin.ReleaseMode)
+	return NewApi(
+		WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+		WithLogger(logger),
+		WithStorage(s),
+		WithSecrets(keyring),
+		WithWorker(),
+	)
+}
+
+func TestSecretHandlers_SetSecret(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		keyring    *secrets.Keyring
+		secretName string
+		body       interface{}
+		wantStatus int
+	}{
+		{
+			name:       "positive_set_secret",
+			storage:    &mock.Storage{},
+			keyring:    testKeyring,
+			secretName: secretName,
+			body:       &models.Secret{Value: "s3cret"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_set_secret_invalid_body_error",
+			storage:    &mock.Storage{},
+			keyring:    testKeyring,
+			secretName: secretName,
+			body:       map[string]interface{}{"value": 65},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_set_secret_invalid_name_error",
+			storage:    &mock.Storage{},
+			keyring:    testKeyring,
+			secretName: "api%20token",
+			body:       &models.Secret{Value: "s3cret"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_set_secret_empty_value_error",
+			storage:    &mock.Storage{},
+			keyring:    testKeyring,
+			secretName: secretName,
+			body:       &models.Secret{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_set_secret_not_configured_error",
+			storage:    &mock.Storage{},
+			secretName: secretName,
+			body:       &models.Secret{Value: "s3cret"},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "negative_set_secret_storage_error",
+			storage:    &mock.Storage{SetSecretErr: true},
+			keyring:    testKeyring,
+			secretName: secretName,
+			body:       &models.Secret{Value: "s3cret"},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newSecretsApi(tt.storage, tt.keyring)
+
+			jsonBody, _ := json.Marshal(tt.body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/api/secrets/%s", tt.secretName), bytes.NewBuffer(jsonBody))
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+			if bytes.Contains(w.Body.Bytes(), []byte("s3cret")) {
+				t.Errorf("Expected secret value to be hidden, got: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSecretHandlers_SetSecret_Sealed(t *testing.T) {
+	s := &mock.Storage{}
+	a := newSecretsApi(s, testKeyring)
+
+	jsonBody, _ := json.Marshal(&models.Secret{Value: "s3cret"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/api/secrets/%s", secretName), bytes.NewBuffer(jsonBody))
+	a.Router.ServeHTTP(w, req)
+	checkResponseStatusCode(t, http.StatusOK, w.Code)
+
+	stored, ok := s.Secrets[secretName]
+	if !ok {
+		t.Fatalf("Expected secret %q to be stored", secretName)
+	}
+	if len(stored.Value) > 0 || bytes.Contains([]byte(stored.Envelope), []byte("s3cret")) {
+		t.Errorf("Expected only sealed value to be stored, got: %+v", stored)
+	}
+	value, err := a.Secrets.Open(stored.Envelope)
+	if err != nil || value != "s3cret" {
+		t.Errorf("Open() = %q, %v, want %q", value, err, "s3cret")
+	}
+}
+
+func TestSecretHandlers_GetSecrets(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		wantStatus int
+	}{
+		{
+			name:       "positive_get_secrets",
+			storage:    &mock.Storage{},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_get_secrets_storage_error",
+			storage:    &mock.Storage{GetSecretsErr: true},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newSecretsApi(tt.storage, testKeyring)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/api/secrets", nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestSecretHandlers_DeleteSecret(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		wantStatus int
+	}{
+		{
+			name:       "positive_delete_secret",
+			storage:    &mock.Storage{},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_delete_secret_storage_error",
+			storage:    &mock.Storage{DeleteSecretErr: true},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newSecretsApi(tt.storage, testKeyring)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api/secrets/%s", secretName), nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestSecretHandlers_RotateSecrets(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    *mock.Storage
+		keyring    *secrets.Keyring
+		wantStatus int
+	}{
+		{
+			name:       "positive_rotate_secrets",
+			storage:    &mock.Storage{},
+			keyring:    testKeyring,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_rotate_secrets_not_configured_error",
+			storage:    &mock.Storage{},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "negative_rotate_secrets_storage_error",
+			storage:    &mock.Storage{RewrapSecretsErr: true},
+			keyring:    testKeyring,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newSecretsApi(tt.storage, tt.keyring)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/api/secrets/rotate", nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const tenantResource = "tenant"
+
+type TenantHandlers struct {
+	storage storage.Storage
+	logger  logrus.FieldLogger
+}
+
+func NewTenantHandlers(s storage.Storage, l logrus.FieldLogger) *TenantHandlers {
+	return &TenantHandlers{
+		storage: s,
+		logger:  l,
+	}
+}
+
+func (h *TenantHandlers) GetTenants(c *gin.Context) {
+	tenants, err := h.storage.GetTenants(c.Request.Context())
+	if err != nil {
+		handlePostgresError(c, h.logger, err, tenantResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenants)
+}
+
+func (h *TenantHandlers) AddTenant(c *gin.Context) {
+	tenant := &models.Tenant{}
+	err := c.ShouldBindJSON(tenant)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
+		return
+	}
+
+	if err = tenant.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+	tenant.Id = 0
+	tenant.CreatedAt = time.Now().Unix()
+
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.AddTenant(c.Request.Context(), tenant); err != nil {
+			return nil, err
+		}
+		return &models.AuditEntry{
+			Action:     models.AuditCreate,
+			Resource:   tenantResource,
+			ResourceId: strconv.Itoa(tenant.Id),
+			After:      *tenant,
+		}, nil
+	})
+	if err != nil {
+		handlePostgresError(c, h.logger, err, tenantResource)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tenant)
+}
+
+// UpdateTenant changes name and quotas of tenant, they apply to fetchers added or updated afterwards
+func (h *TenantHandlers) UpdateTenant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - tenant id"})
+		return
+	}
+
+	tenant := &models.Tenant{}
+	err = c.ShouldBindJSON(tenant)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
+		return
+	}
+
+	if err = tenant.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+	tenant.Id = id
+
+	before, err := h.storage.GetTenant(c.Request.Context(), id)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, tenantResource)
+		return
+	}
+
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.UpdateTenant(c.Request.Context(), tenant); err != nil {
+			return nil, err
+		}
+		return &models.AuditEntry{
+			Action:     models.AuditUpdate,
+			Resource:   tenantResource,
+			ResourceId: strconv.Itoa(id),
+			Before:     *before,
+			After:      *tenant,
+		}, nil
+	})
+	if err != nil {
+		handlePostgresError(c, h.logger, err, tenantResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
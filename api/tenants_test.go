@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/gin-gonic/gin"
+)
+
+func newTenantsApi(s storage.Storage) *Api {
+	gin.SetMode(gin.ReleaseMode)
+	return NewApi(
+		WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+		WithLogger(logger),
+		WithStorage(s),
+		WithWorker(),
+	)
+}
+
+func TestTenantHandlers_GetTenants(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    storage.Storage
+		wantStatus int
+	}{
+		{
+			name:       "positive_get_tenants",
+			storage:    &mock.Storage{},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_get_tenants_storage_error",
+			storage:    &mock.Storage{GetTenantsErr: true},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTenantsApi(tt.storage)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/api/tenants", nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestTenantHandlers_AddTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    storage.Storage
+		body       interface{}
+		wantStatus int
+	}{
+		{
+			name:       "positive_add_tenant",
+			storage:    &mock.Storage{},
+			body:       &models.Tenant{Name: "team-a", MaxFetchers: 10, MinInterval: 60},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "negative_add_tenant_invalid_body_error",
+			storage:    &mock.Storage{},
+			body:       map[string]interface{}{"max_fetchers": "10"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_add_tenant_validation_error",
+			storage:    &mock.Storage{},
+			body:       &models.Tenant{Name: "team-a", MaxFetchers: -1},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_add_tenant_storage_error",
+			storage:    &mock.Storage{AddTenantErr: true},
+			body:       &models.Tenant{Name: "team-a"},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTenantsApi(tt.storage)
+
+			jsonBody, _ := json.Marshal(tt.body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/api/tenants", bytes.NewBuffer(jsonBody))
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestTenantHandlers_UpdateTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		storage    storage.Storage
+		tenantId   string
+		body       interface{}
+		wantStatus int
+	}{
+		{
+			name:       "positive_update_tenant",
+			storage:    &mock.Storage{},
+			tenantId:   validId,
+			body:       &models.Tenant{Name: "team-a", MaxFetchers: 20},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_update_tenant_invalid_id_error",
+			storage:    &mock.Storage{},
+			tenantId:   invalidId,
+			body:       &models.Tenant{Name: "team-a"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_update_tenant_validation_error",
+			storage:    &mock.Storage{},
+			tenantId:   validId,
+			body:       &models.Tenant{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative_update_tenant_storage_error",
+			storage:    &mock.Storage{UpdateTenantErr: true},
+			tenantId:   validId,
+			body:       &models.Tenant{Name: "team-a"},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTenantsApi(tt.storage)
+
+			jsonBody, _ := json.Marshal(tt.body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/api/tenants/%s", tt.tenantId), bytes.NewBuffer(jsonBody))
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestApi_TenantIsolation(t *testing.T) {
+	rule := `{"fetcher_id":5,"type":"consecutive_failures","failures":3}`
+	fetcher := fmt.Sprintf(`{"url":%q,"interval":60}`, exampleUrl)
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "negative_get_fetcher_of_other_tenant_error", method: http.MethodGet, url: "/api/fetcher/5", wantStatus: http.StatusBadRequest},
+		{name: "negative_update_fetcher_of_other_tenant_error", method: http.MethodPut, url: "/api/fetcher/5", body: fetcher, wantStatus: http.StatusBadRequest},
+		{name: "negative_delete_fetcher_of_other_tenant_error", method: http.MethodDelete, url: "/api/fetcher/5", wantStatus: http.StatusBadRequest},
+		{name: "negative_pause_fetcher_of_other_tenant_error", method: http.MethodPost, url: "/api/fetcher/5/pause", wantStatus: http.StatusBadRequest},
+		{name: "negative_get_history_of_other_tenant_error", method: http.MethodGet, url: "/api/fetcher/5/history", wantStatus: http.StatusBadRequest},
+		{name: "negative_get_stats_of_other_tenant_error", method: http.MethodGet, url: "/api/fetcher/5/stats", wantStatus: http.StatusBadRequest},
+		{name: "negative_get_revisions_of_other_tenant_error", method: http.MethodGet, url: "/api/fetcher/5/revisions", wantStatus: http.StatusBadRequest},
+		{name: "negative_get_rules_of_other_tenant_error", method: http.MethodGet, url: "/api/fetcher/5/rules", wantStatus: http.StatusBadRequest},
+		{name: "negative_add_rule_to_other_tenant_error", method: http.MethodPost, url: "/api/fetcher/5/rules", body: rule, wantStatus: http.StatusBadRequest},
+		{name: "negative_delete_rule_of_other_tenant_error", method: http.MethodDelete, url: "/api/fetcher/5/rules/1", wantStatus: http.StatusBadRequest},
+		{name: "positive_get_fetchers_hides_other_tenant", method: http.MethodGet, url: "/api/fetcher", wantStatus: http.StatusOK, wantBody: "[]"},
+		{name: "positive_bulk_delete_skips_other_tenant", method: http.MethodPost, url: "/api/bulk/fetchers/delete?selector=env", wantStatus: http.StatusOK, wantBody: `{"ids":[]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &mock.Storage{
+				Fetchers: []models.Fetcher{{Id: 5, TenantId: models.DefaultTenantId, Url: exampleUrl, Interval: 60, Labels: map[string]string{"env": "prod"}}},
+				ApiKeys: []models.ApiKey{
+					{Id: 1, TenantId: 2, Name: "tenant admin", Hash: models.HashApiKey(tenantAdminKey), Scopes: []string{models.ScopeAdmin}},
+				},
+			}
+			a := newAuthApi(s)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tenantAdminKey)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+			if len(tt.wantBody) > 0 && w.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %s, got: %s", tt.wantBody, w.Body.String())
+			}
+
+			// the fetcher is still there for its own tenant
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest(http.MethodGet, "/api/fetcher/5", nil)
+			req.Header.Set("Authorization", "Bearer "+adminKey)
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, http.StatusOK, w.Code)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/sdk/export/trace/tracetest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestApi_Tracing(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		storage    *mock.Storage
+		wantSpans  []string
+		wantStatus int
+	}{
+		{
+			name:       "positive_get_fetchers",
+			method:     http.MethodGet,
+			url:        "/api/fetcher",
+			storage:    &mock.Storage{},
+			wantSpans:  []string{"storage.GetFetchers", "GET /api/fetcher"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative_get_history_storage_error",
+			method:     http.MethodGet,
+			url:        "/api/fetcher/3/history",
+			storage:    &mock.Storage{GetHistoryErr: true},
+			wantSpans:  []string{"storage.GetHistory", "GET /api/fetcher/:id/history"},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "negative_unmatched_route",
+			method:     http.MethodGet,
+			url:        "/api/unknown",
+			storage:    &mock.Storage{},
+			wantSpans:  []string{"GET unmatched"},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			provider, err := sdktrace.NewProvider(
+				sdktrace.WithSyncer(exporter),
+				sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(&config.Config{Api: config.Api{MaxContentLength: 1024}}),
+				WithLogger(logger),
+				WithTraceProvider(provider),
+				WithStorage(tt.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.url, nil)
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+
+			spans := exporter.GetSpans()
+			if len(spans) != len(tt.wantSpans) {
+				t.Fatalf("Expected %d spans, got: %d", len(tt.wantSpans), len(spans))
+			}
+			for i, span := range spans {
+				if span.Name != tt.wantSpans[i] {
+					t.Errorf("Expected span %q, got: %q", tt.wantSpans[i], span.Name)
+				}
+			}
+
+			server := spans[len(spans)-1]
+			if server.SpanKind != trace.SpanKindServer {
+				t.Errorf("Expected server span kind, got: %s", server.SpanKind)
+			}
+			for _, span := range spans[:len(spans)-1] {
+				if span.ParentSpanID != server.SpanContext.SpanID || span.SpanContext.TraceID != server.SpanContext.TraceID {
+					t.Errorf("Expected span %q to be child of server span, got parent: %s", span.Name, span.ParentSpanID)
+				}
+			}
+		})
+	}
+}
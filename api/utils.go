@@ -2,20 +2,27 @@ package api
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 
+	"github.com/BarTar213/bartlomiej-tarczynski/logging"
 	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
 	"github.com/gin-gonic/gin"
 	"github.com/go-pg/pg/v10"
+	"github.com/sirupsen/logrus"
 )
 
-func handlePostgresError(c *gin.Context, l *log.Logger, err error, resource string) {
+func handlePostgresError(c *gin.Context, l logrus.FieldLogger, err error, resource string) {
+	status, msg := postgresError(c, l, err, resource)
+	c.JSON(status, models.Response{Error: msg})
+}
+
+// postgresError logs unexpected storage error and returns status code and message to respond with
+func postgresError(c *gin.Context, l logrus.FieldLogger, err error, resource string) (int, string) {
 	if err == pg.ErrNoRows {
-		c.JSON(http.StatusBadRequest, models.Response{Error: fmt.Sprintf("%s with given id doesn't exist", resource)})
-		return
+		return http.StatusBadRequest, fmt.Sprintf("%s with given id doesn't exist", resource)
 	}
-	l.Println(err)
+	requestLogger(c, l).WithError(err).WithField("resource", resource).Error("Storage error")
 
 	pgErr, ok := err.(pg.Error)
 	if ok {
@@ -29,12 +36,47 @@ func handlePostgresError(c *gin.Context, l *log.Logger, err error, resource stri
 			msg = fmt.Sprintf("%s with given id already exists", resource)
 		}
 		if len(msg) > 0 {
-			c.JSON(status, models.Response{Error: msg})
-			return
+			return status, msg
 		}
 	}
 
-	c.JSON(http.StatusInternalServerError, models.Response{Error: "storage error"})
+	return http.StatusInternalServerError, "storage error"
+}
+
+// isUniqueViolation tells whether err was caused by duplicate in unique index or constraint with given name
+func isUniqueViolation(err error, constraint string) bool {
+	pgErr, ok := err.(pg.Error)
+	return ok && pgErr.Field('C') == "23505" && pgErr.Field('n') == constraint
+}
+
+func handleSecretError(c *gin.Context, l logrus.FieldLogger, err error) {
+	if err == secrets.ErrNotConfigured {
+		c.JSON(http.StatusServiceUnavailable, models.Response{Error: err.Error()})
+		return
+	}
+	requestLogger(c, l).WithError(err).Error("Secret store error")
+
+	c.JSON(http.StatusInternalServerError, models.Response{Error: "secret store error"})
+}
+
+// sealSecret replaces plaintext secret with its envelope, it responds with error and returns false on failure
+func sealSecret(c *gin.Context, l logrus.FieldLogger, sm *secrets.Manager, secret *string) bool {
+	if len(*secret) == 0 {
+		return true
+	}
+
+	sealed, err := sm.Seal(*secret)
+	if err != nil {
+		handleSecretError(c, l, err)
+		return false
+	}
+	*secret = sealed
+
+	return true
+}
+
+func requestLogger(c *gin.Context, l logrus.FieldLogger) logrus.FieldLogger {
+	return l.WithField(logging.RequestIdKey, c.GetString(logging.RequestIdKey))
 }
 
 func (h *FetcherHandlers) ReturnFetcher(f *models.Fetcher) {
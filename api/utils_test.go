@@ -1,7 +1,6 @@
 package api
 
 import (
-	"log"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,11 +8,12 @@ import (
 	"github.com/BarTar213/bartlomiej-tarczynski/mock"
 	"github.com/gin-gonic/gin"
 	"github.com/go-pg/pg/v10"
+	"github.com/sirupsen/logrus"
 )
 
 func Test_handlePostgresError(t *testing.T) {
 	type args struct {
-		logger   *log.Logger
+		logger   *logrus.Logger
 		err      error
 		resource string
 	}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/destination"
+	"github.com/BarTar213/bartlomiej-tarczynski/middleware"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const webhookResource = "webhook"
+
+type WebhookHandlers struct {
+	storage storage.Storage
+	secrets *secrets.Manager
+	policy  *destination.Policy
+	logger  logrus.FieldLogger
+}
+
+func NewWebhookHandlers(s storage.Storage, sm *secrets.Manager, policy *destination.Policy, l logrus.FieldLogger) *WebhookHandlers {
+	return &WebhookHandlers{
+		storage: s,
+		secrets: sm,
+		policy:  policy,
+		logger:  l,
+	}
+}
+
+func (h *WebhookHandlers) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.storage.GetWebhooks(c.Request.Context(), middleware.CurrentTenant(c))
+	if err != nil {
+		handlePostgresError(c, h.logger, err, webhookResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandlers) AddWebhook(c *gin.Context) {
+	webhook := &models.Webhook{}
+	err := c.ShouldBindJSON(webhook)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: invalidBodyErr})
+		return
+	}
+	webhook.TenantId = middleware.CurrentTenant(c)
+
+	if err = webhook.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+	if err = h.policy.CheckUrl(c.Request.Context(), webhook.Url); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: err.Error()})
+		return
+	}
+	if !sealSecret(c, h.logger, h.secrets, &webhook.Secret) {
+		return
+	}
+
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.AddWebhook(c.Request.Context(), webhook); err != nil {
+			return nil, err
+		}
+		after := *webhook
+		after.Secret = ""
+		return &models.AuditEntry{
+			Action:     models.AuditCreate,
+			Resource:   webhookResource,
+			ResourceId: strconv.Itoa(webhook.Id),
+			After:      after,
+		}, nil
+	})
+	if err != nil {
+		handlePostgresError(c, h.logger, err, webhookResource)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Id{Id: webhook.Id})
+}
+
+func (h *WebhookHandlers) DeleteWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - webhook id"})
+		return
+	}
+
+	err = audited(c, h.storage, func(s storage.Storage) (*models.AuditEntry, error) {
+		if err := s.DeleteWebhook(c.Request.Context(), middleware.CurrentTenant(c), id); err != nil {
+			return nil, err
+		}
+		return &models.AuditEntry{
+			Action:     models.AuditDelete,
+			Resource:   webhookResource,
+			ResourceId: strconv.Itoa(id),
+		}, nil
+	})
+	if err != nil {
+		handlePostgresError(c, h.logger, err, webhookResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{})
+}
+
+func (h *WebhookHandlers) GetWebhookDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param(idKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{Error: "invalid query param - webhook id"})
+		return
+	}
+
+	deliveries, err := h.storage.GetWebhookDeliveries(c.Request.Context(), middleware.CurrentTenant(c), id)
+	if err != nil {
+		handlePostgresError(c, h.logger, err, webhookResource)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
@@ -0,0 +1,352 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWebhookHandlers_GetWebhooks(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		logger  *logrus.Logger
+		conf    *config.Config
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		wantStatus int
+	}{
+		{
+			name: "positive_get_webhooks",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_webhooks_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					GetWebhooksErr: true,
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := "/api/webhooks"
+			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestWebhookHandlers_AddWebhook(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		keyring *secrets.Keyring
+		logger  *logrus.Logger
+		conf    *config.Config
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		body       interface{}
+		wantStatus int
+	}{
+		{
+			name: "positive_add_webhook",
+			fields: fields{
+				storage: &mock.Storage{},
+				keyring: testKeyring,
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			body: &models.Webhook{
+				Url:    exampleUrl,
+				Secret: "secret",
+				Events: []string{models.EventFetchFailed},
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "negative_add_webhook_content_too_large_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				keyring: testKeyring,
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1},
+				},
+			},
+			body: &models.Webhook{
+				Url:    exampleUrl,
+				Secret: "secret",
+				Events: []string{models.EventFetchFailed},
+			},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "negative_add_webhook_invalid_body_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				keyring: testKeyring,
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			body: map[string]interface{}{
+				urlKey: 65,
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_add_webhook_validation_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				keyring: testKeyring,
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			body: &models.Webhook{
+				Url: exampleUrl,
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_add_webhook_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					AddWebhookErr: true,
+				},
+				keyring: testKeyring,
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			body: &models.Webhook{
+				Url:    exampleUrl,
+				Secret: "secret",
+				Events: []string{models.EventFetchFailed},
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "negative_add_webhook_secret_store_not_configured_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			body: &models.Webhook{
+				Url:    exampleUrl,
+				Secret: "secret",
+				Events: []string{models.EventFetchFailed},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithSecrets(tt.fields.keyring),
+				WithWorker(),
+			)
+
+			jsonBody, _ := json.Marshal(tt.body)
+
+			w := httptest.NewRecorder()
+			reqUrl := "/api/webhooks"
+			req, _ := http.NewRequest(http.MethodPost, reqUrl, bytes.NewBuffer(jsonBody))
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestWebhookHandlers_DeleteWebhook(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		logger  *logrus.Logger
+		conf    *config.Config
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		webhookId  string
+		wantStatus int
+	}{
+		{
+			name: "positive_delete_webhook",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			webhookId:  validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_delete_webhook_invalid_id_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			webhookId:  invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_delete_webhook_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					DeleteWebhookErr: true,
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			webhookId:  validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/api/webhooks/%s", tt.webhookId)
+			req, _ := http.NewRequest(http.MethodDelete, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestWebhookHandlers_GetWebhookDeliveries(t *testing.T) {
+	type fields struct {
+		storage storage.Storage
+		logger  *logrus.Logger
+		conf    *config.Config
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		webhookId  string
+		wantStatus int
+	}{
+		{
+			name: "positive_get_webhook_deliveries",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			webhookId:  validId,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "negative_get_webhook_deliveries_invalid_id_error",
+			fields: fields{
+				storage: &mock.Storage{},
+				logger:  logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			webhookId:  invalidId,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative_get_webhook_deliveries_storage_error",
+			fields: fields{
+				storage: &mock.Storage{
+					GetWebhookDeliveriesErr: true,
+				},
+				logger: logger,
+				conf: &config.Config{
+					Api: config.Api{MaxContentLength: 1024},
+				},
+			},
+			webhookId:  validId,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.ReleaseMode)
+			a := NewApi(
+				WithConfig(tt.fields.conf),
+				WithLogger(tt.fields.logger),
+				WithStorage(tt.fields.storage),
+				WithWorker(),
+			)
+
+			w := httptest.NewRecorder()
+			reqUrl := fmt.Sprintf("/api/webhooks/%s/deliveries", tt.webhookId)
+			req, _ := http.NewRequest(http.MethodGet, reqUrl, nil)
+
+			a.Router.ServeHTTP(w, req)
+			checkResponseStatusCode(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -1,43 +1,124 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"log"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/BarTar213/bartlomiej-tarczynski/api"
 	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/destination"
+	"github.com/BarTar213/bartlomiej-tarczynski/logging"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
 	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/BarTar213/bartlomiej-tarczynski/tracing"
+	"github.com/sirupsen/logrus"
 )
 
 func main() {
 	configFile := flag.String("fetcher-config", "fetcher.yml", "name of yml file with fetcher config")
 	conf := config.NewConfig(configFile)
-	logger := log.New(os.Stdout, "", log.LstdFlags)
+	logger, err := logging.New(conf.Log)
+	if err != nil {
+		logrus.Fatalf("Logger error: %s", err)
+	}
+
+	logger.WithField("config", conf.Redacted()).Info("loaded config")
+
+	provider, stopTracing, err := tracing.NewProvider(conf.Tracing)
+	if err != nil {
+		logger.WithError(err).Fatal("Tracing error")
+	}
+
+	keyring, err := secrets.NewKeyring(conf.Secrets)
+	if err != nil {
+		logger.WithError(err).Fatal("Keyring error")
+	}
+	if keyring == nil {
+		logger.Warn("secret store is not configured")
+	}
 
-	logger.Printf("%+v\n", conf)
+	policy, err := destination.NewPolicy(conf.Destinations)
+	if err != nil {
+		logger.WithError(err).Fatal("Destinations error")
+	}
+
+	if !conf.Api.RequireAuth {
+		logger.Warn("api authentication is disabled")
+	}
+	if conf.Api.RequireAuth && conf.Api.AdminKey == config.PlaceholderAdminKey {
+		logger.Warn("api.adminKey is the shipped placeholder, replace it")
+	}
 
 	postgres, err := storage.NewPostgres(&conf.Postgres)
 	if err != nil {
-		logger.Fatalf("Postgres error: %s", err)
+		logger.WithError(err).Fatal("Postgres error")
+	}
+
+	// without admin key and stored operator key nobody could create the first key
+	if conf.Api.RequireAuth && len(conf.Api.AdminKey) == 0 {
+		ok, err := hasOperatorKey(postgres)
+		if err != nil {
+			logger.WithError(err).Fatal("Api keys error")
+		}
+		if !ok {
+			logger.Fatal("api authentication requires api.adminKey until first admin key is created")
+		}
 	}
 
 	a := api.NewApi(
 		api.WithConfig(conf),
 		api.WithLogger(logger),
+		api.WithTraceProvider(provider),
 		api.WithStorage(postgres),
+		api.WithSecrets(keyring),
+		api.WithDestinations(policy),
 		api.WithWorker(),
 	)
 
+	if a.Secrets != nil {
+		sealed, err := a.Secrets.SealLegacy(context.Background())
+		if err != nil {
+			logger.WithError(err).Fatal("Sealing legacy secrets error")
+		}
+		if sealed > 0 {
+			logger.WithField("count", sealed).Info("sealed legacy secrets")
+		}
+	}
+
+	// jobs are registered before api starts, so fetchers added meanwhile aren't registered twice
+	err = a.Worker.Resync(context.Background())
+	if err != nil {
+		logger.WithError(err).Fatal("Resync error")
+	}
+	logger.Info("synced fetchers")
+
 	go a.Run()
-	logger.Print("started app")
+	logger.Info("started app")
 
 	shutDownSignal := make(chan os.Signal, 1)
 	signal.Notify(shutDownSignal, syscall.SIGINT, syscall.SIGTERM)
 
 	<-shutDownSignal
 	a.Worker.Stop()
-	logger.Print("exited from app")
+	stopTracing()
+	logger.Info("exited from app")
+}
+
+// hasOperatorKey tells whether default tenant has admin key which isn't revoked
+func hasOperatorKey(s storage.Storage) (bool, error) {
+	keys, err := s.GetApiKeys(context.Background(), models.DefaultTenantId)
+	if err != nil {
+		return false, err
+	}
+	for i := range keys {
+		if keys[i].RevokedAt == nil && keys[i].IsOperator() {
+			return true, nil
+		}
+	}
+
+	return false, nil
 }
@@ -1,19 +1,41 @@
 package config
 
 import (
-	"log"
+	"net/url"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
+const redacted = "[REDACTED]"
+
+// PlaceholderAdminKey is admin key of the shipped configuration, meant to be replaced before deployment
+const PlaceholderAdminKey = "change-me"
+
 type Config struct {
-	Api      Api
-	Postgres Postgres
+	Api          Api
+	Postgres     Postgres
+	Webhook      Webhook
+	Smtp         Smtp
+	Log          Log
+	Tracing      Tracing
+	Certs        Certs
+	Tls          Tls
+	Secrets      Secrets
+	Proxy        Proxy
+	Destinations Destinations
+	RateLimit    RateLimit
 }
 
+// Api.RequireAuth enables api key authentication, AdminKey (may be a ${secret:name} reference) is accepted
+// as admin key, so first keys can be created
 type Api struct {
 	Port             string
 	MaxContentLength int64
+	PublicUrl        string
+	RequireAuth      bool
+	AdminKey         string
 }
 
 type Postgres struct {
@@ -23,21 +45,136 @@ type Postgres struct {
 	Database string
 }
 
+type Log struct {
+	Level  string
+	Format string
+}
+
+// Tracing selects span exporter, empty Exporter keeps tracing disabled (no-op)
+type Tracing struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+}
+
+// Secrets points to file with master keys of the secret store, FETCHER_MASTER_KEY env takes precedence
+type Secrets struct {
+	KeyFile string
+}
+
+// Proxy routes fetcher requests through HTTP(S) or SOCKS5 proxy, credentials go in url user info.
+// Hosts in NoProxy (NO_PROXY syntax) are reached directly. Empty Url falls back to HTTP_PROXY/HTTPS_PROXY/NO_PROXY env.
+type Proxy struct {
+	Url     string
+	NoProxy string
+}
+
+// Destinations limit where fetchers may connect to. Entries are CIDRs, IPs, host names or ".domain" / "*.domain"
+// suffixes. Private, loopback and link-local ranges are blocked unless AllowPrivate is set or they are allowed explicitly.
+type Destinations struct {
+	Allow        []string
+	Deny         []string
+	AllowPrivate bool
+}
+
+// RateLimit limits api requests per api key, or per client IP for anonymous requests. Read applies to GET requests
+// and Write to the others, Auth to requests with missing or invalid api key. Zero PerMinute disables the limit,
+// zero Burst defaults to PerMinute. X-Forwarded-For is used for client IP only behind TrustedProxies (IPs or CIDRs).
+type RateLimit struct {
+	Read           Limit
+	Write          Limit
+	Auth           Limit
+	TrustedProxies []string
+}
+
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+// Certs configures TLS certificate expiry warnings
+type Certs struct {
+	WarningDays int
+}
+
+// Tls.Dir is directory with PEM files (CA bundles, client certs and keys) fetcher tls settings may refer to by path
+// relative to it. Fetchers can't use files without it, nor files outside of it.
+type Tls struct {
+	Dir string
+}
+
+const defaultCertWarningDays = 14
+
+// WarningWindow returns how long before expiry the warning is raised
+func (c Certs) WarningWindow() time.Duration {
+	days := c.WarningDays
+	if days <= 0 {
+		days = defaultCertWarningDays
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+type Webhook struct {
+	Retries int
+	Backoff time.Duration
+	Timeout time.Duration
+}
+
+type Smtp struct {
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	StartTLS  bool
+	From      string
+	Templates EmailTemplates
+}
+
+// EmailTemplates are text/template sources executed with models.Event, empty ones fall back to defaults
+type EmailTemplates struct {
+	AlertFired     EmailTemplate
+	AlertResolved  EmailTemplate
+	ContentChanged EmailTemplate
+}
+
+type EmailTemplate struct {
+	Subject string
+	Body    string
+}
+
 func NewConfig(fileName *string) *Config {
-	fmt.Printf("test")
 	viper.SetConfigFile(*fileName)
 	viper.SetConfigType("yaml")
 
 	var config Config
 
 	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalf("Error reading config file, %s", err)
+		logrus.Fatalf("Error reading config file, %s", err)
 	}
 
 	err := viper.Unmarshal(&config)
 	if err != nil {
-		log.Fatalf("unable to decode into struct, %v", err)
+		logrus.Fatalf("unable to decode into struct, %v", err)
 	}
 
 	return &config
 }
+
+// Redacted returns copy of config with secrets masked, so it can be safely logged
+func (c Config) Redacted() Config {
+	if len(c.Postgres.Password) > 0 {
+		c.Postgres.Password = redacted
+	}
+	if len(c.Smtp.Password) > 0 {
+		c.Smtp.Password = redacted
+	}
+	if len(c.Api.AdminKey) > 0 {
+		c.Api.AdminKey = redacted
+	}
+	if u, err := url.Parse(c.Proxy.Url); err == nil && u.User != nil {
+		c.Proxy.Url = u.Redacted()
+	}
+	return c
+}
@@ -0,0 +1,280 @@
+package destination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+)
+
+const lookupTimeout = 2 * time.Second
+
+var ErrBlocked = errors.New("destination not allowed")
+
+// privateRanges are loopback, private, link-local (cloud metadata), carrier-grade NAT, unspecified and multicast ranges
+var privateRanges = parseCidrs(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+	"192.168.0.0/16", "224.0.0.0/4", "::/128", "::1/128", "fc00::/7", "fe80::/10", "ff00::/8",
+)
+
+// Policy decides which hosts fetchers may connect to. Deny list wins over allow list; private ranges are
+// blocked unless private destinations are enabled or address is allowed explicitly; with non-empty allow list
+// only allowed destinations are reachable. Nil Policy allows everything.
+type Policy struct {
+	allowNets    []*net.IPNet
+	allowHosts   []string
+	denyNets     []*net.IPNet
+	denyHosts    []string
+	allowPrivate bool
+}
+
+func NewPolicy(conf config.Destinations) (*Policy, error) {
+	p := &Policy{allowPrivate: conf.AllowPrivate}
+
+	var err error
+	if p.allowNets, p.allowHosts, err = parseEntries(conf.Allow); err != nil {
+		return nil, err
+	}
+	if p.denyNets, p.denyHosts, err = parseEntries(conf.Deny); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// CheckHost checks host name or IP literal before it is resolved
+func (p *Policy) CheckHost(host string) error {
+	if p == nil {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return p.CheckIP(host, ip)
+	}
+
+	host = normalize(host)
+	if matchHost(p.denyHosts, host) {
+		return blocked(host, "denied host")
+	}
+	// with CIDRs in allow list host can be allowed by its addresses, so it is decided after resolution
+	if len(p.allowHosts) > 0 && len(p.allowNets) == 0 && !matchHost(p.allowHosts, host) {
+		return blocked(host, "host not in allow list")
+	}
+
+	return nil
+}
+
+// CheckIP checks address host was resolved to
+func (p *Policy) CheckIP(host string, ip net.IP) error {
+	if p == nil {
+		return nil
+	}
+
+	host = normalize(host)
+	if matchNet(p.denyNets, ip) || matchHost(p.denyHosts, host) {
+		return blocked(ip.String(), "denied address")
+	}
+	if matchNet(p.allowNets, ip) || matchHost(p.allowHosts, host) {
+		return nil
+	}
+	if len(p.allowNets)+len(p.allowHosts) > 0 {
+		return blocked(ip.String(), "address not in allow list")
+	}
+	if !p.allowPrivate && matchNet(privateRanges, ip) {
+		return blocked(ip.String(), "private address")
+	}
+
+	return nil
+}
+
+// CheckUrl checks url host and, if it resolves, all its addresses. Unresolvable hosts pass,
+// as they are checked again when connection is made.
+func (p *Policy) CheckUrl(ctx context.Context, rawUrl string) error {
+	if p == nil {
+		return nil
+	}
+
+	// secret references can't be resolved here, they are replaced just to parse the url
+	u, err := url.Parse(models.SecretReference.ReplaceAllString(rawUrl, "secret"))
+	if err != nil {
+		return nil
+	}
+
+	return p.checkResolved(ctx, u.Hostname(), false)
+}
+
+// checkResolved checks host and all addresses it resolves to. Unresolvable hosts are blocked when strict is set,
+// unless they are allowed by name.
+func (p *Policy) checkResolved(ctx context.Context, host string, strict bool) error {
+	if err := p.CheckHost(host); err != nil || net.ParseIP(host) != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		if strict && !matchHost(p.allowHosts, normalize(host)) {
+			return blocked(host, "unresolvable host")
+		}
+		return nil
+	}
+	for _, addr := range addrs {
+		if err = p.CheckIP(host, addr.IP); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DialContext wraps dialer, so every address it connects to is checked after resolution,
+// which also covers redirects and DNS answers changing after validation. Exempt addresses ("host:port"
+// of proxies configured by operator) are dialed without checks, so proxy in private network doesn't
+// have to be allowed for all fetchers.
+func (p *Policy) DialContext(dialer *net.Dialer, exempt ...string) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if p == nil {
+		return dialer.DialContext
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range exempt {
+			if strings.EqualFold(e, address) {
+				return dialer.DialContext(ctx, network, address)
+			}
+		}
+		if err = p.CheckHost(host); err != nil {
+			return nil, err
+		}
+
+		d := *dialer
+		d.Control = func(network, address string, _ syscall.RawConn) error {
+			ip, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if i := strings.IndexByte(ip, '%'); i >= 0 {
+				ip = ip[:i]
+			}
+			return p.CheckIP(host, net.ParseIP(ip))
+		}
+
+		return d.DialContext(ctx, network, address)
+	}
+}
+
+// Proxy wraps transport proxy function. Proxy resolves target itself, so target is resolved here and all its
+// addresses are checked; targets which don't resolve are blocked unless allowed by name.
+func (p *Policy) Proxy(proxy func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	if p == nil || proxy == nil {
+		return proxy
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		proxyUrl, err := proxy(req)
+		if err != nil || proxyUrl == nil {
+			return proxyUrl, err
+		}
+		if err = p.checkResolved(req.Context(), req.URL.Hostname(), true); err != nil {
+			return nil, err
+		}
+
+		return proxyUrl, nil
+	}
+}
+
+// ProxyAddr returns "host:port" transport dials to reach proxy, with default port of its scheme
+func ProxyAddr(proxyUrl *url.URL) string {
+	port := proxyUrl.Port()
+	if len(port) == 0 {
+		switch proxyUrl.Scheme {
+		case "https":
+			port = "443"
+		case "socks5":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+
+	return net.JoinHostPort(proxyUrl.Hostname(), port)
+}
+
+func blocked(destination, reason string) error {
+	return fmt.Errorf("%w: %s (%s)", ErrBlocked, destination, reason)
+}
+
+func parseEntries(entries []string) ([]*net.IPNet, []string, error) {
+	var nets []*net.IPNet
+	var hosts []string
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case len(entry) == 0:
+			continue
+		case strings.Contains(entry, "/"):
+			_, n, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid destination cidr %q", entry)
+			}
+			nets = append(nets, n)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		default:
+			hosts = append(hosts, normalize(strings.TrimPrefix(entry, "*")))
+		}
+	}
+
+	return nets, hosts, nil
+}
+
+func parseCidrs(cidrs ...string) []*net.IPNet {
+	nets, _, err := parseEntries(cidrs)
+	if err != nil {
+		panic(err)
+	}
+
+	return nets
+}
+
+// matchHost matches host exactly or, for patterns starting with a dot, any of its subdomains
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if host == pattern || (strings.HasPrefix(pattern, ".") && strings.HasSuffix(host, pattern)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchNet(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalize(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,299 @@
+package destination
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+)
+
+func TestPolicy_CheckIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    config.Destinations
+		host    string
+		ip      string
+		wantErr bool
+	}{
+		{
+			name: "positive_public_address",
+			host: "example.com",
+			ip:   "93.184.216.34",
+		},
+		{
+			name:    "negative_metadata_address_error",
+			host:    "169.254.169.254",
+			ip:      "169.254.169.254",
+			wantErr: true,
+		},
+		{
+			name:    "negative_loopback_error",
+			host:    "localhost",
+			ip:      "127.0.0.1",
+			wantErr: true,
+		},
+		{
+			name:    "negative_private_ipv6_error",
+			host:    "internal",
+			ip:      "fd00::1",
+			wantErr: true,
+		},
+		{
+			name:    "negative_mapped_private_error",
+			host:    "internal",
+			ip:      "::ffff:10.0.0.1",
+			wantErr: true,
+		},
+		{
+			name: "positive_allow_private",
+			conf: config.Destinations{AllowPrivate: true},
+			host: "internal",
+			ip:   "10.0.0.1",
+		},
+		{
+			name: "positive_allowed_cidr",
+			conf: config.Destinations{Allow: []string{"10.1.0.0/16"}},
+			host: "internal",
+			ip:   "10.1.2.3",
+		},
+		{
+			name: "positive_allowed_host_suffix",
+			conf: config.Destinations{Allow: []string{"*.corp.example.com"}},
+			host: "api.corp.example.com",
+			ip:   "10.1.2.3",
+		},
+		{
+			name:    "negative_not_in_allow_list_error",
+			conf:    config.Destinations{Allow: []string{"10.1.0.0/16"}},
+			host:    "example.com",
+			ip:      "93.184.216.34",
+			wantErr: true,
+		},
+		{
+			name:    "negative_denied_cidr_error",
+			conf:    config.Destinations{Deny: []string{"93.184.216.0/24"}},
+			host:    "example.com",
+			ip:      "93.184.216.34",
+			wantErr: true,
+		},
+		{
+			name:    "negative_denied_wins_over_allowed_error",
+			conf:    config.Destinations{Allow: []string{".example.com"}, Deny: []string{"93.184.216.34"}},
+			host:    "www.example.com",
+			ip:      "93.184.216.34",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPolicy(tt.conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = p.CheckIP(tt.host, net.ParseIP(tt.ip))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckIP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrBlocked) {
+				t.Errorf("Expected ErrBlocked, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestPolicy_CheckHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    config.Destinations
+		host    string
+		wantErr bool
+	}{
+		{
+			name: "positive_unlisted_host",
+			host: "example.com",
+		},
+		{
+			name:    "negative_denied_host_error",
+			conf:    config.Destinations{Deny: []string{"Admin.Example.com"}},
+			host:    "admin.example.com.",
+			wantErr: true,
+		},
+		{
+			name:    "negative_host_not_in_allow_list_error",
+			conf:    config.Destinations{Allow: []string{".example.com"}},
+			host:    "example.org",
+			wantErr: true,
+		},
+		{
+			name:    "negative_private_ip_literal_error",
+			host:    "192.168.1.1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewPolicy(tt.conf)
+			if err := p.CheckHost(tt.host); (err != nil) != tt.wantErr {
+				t.Errorf("CheckHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicy_CheckUrl(t *testing.T) {
+	p, _ := NewPolicy(config.Destinations{})
+	if err := p.CheckUrl(context.Background(), "http://169.254.169.254/latest/meta-data"); !errors.Is(err, ErrBlocked) {
+		t.Errorf("CheckUrl() error = %v, want %v", err, ErrBlocked)
+	}
+	if err := p.CheckUrl(context.Background(), "https://93.184.216.34/?key=${secret:token}"); err != nil {
+		t.Errorf("CheckUrl() error = %v, want nil", err)
+	}
+
+	var nilPolicy *Policy
+	if err := nilPolicy.CheckUrl(context.Background(), "http://127.0.0.1"); err != nil {
+		t.Errorf("CheckUrl() of nil policy error = %v, want nil", err)
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	if _, err := NewPolicy(config.Destinations{Deny: []string{"10.0.0.0/33"}}); err == nil {
+		t.Error("Expected invalid cidr error")
+	}
+}
+
+func TestPolicy_DialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		name    string
+		conf    config.Destinations
+		url     string
+		wantErr bool
+	}{
+		{
+			name:    "negative_loopback_error",
+			url:     server.URL,
+			wantErr: true,
+		},
+		{
+			name:    "negative_resolved_loopback_error",
+			url:     "http://localhost:" + serverUrl.Port(),
+			wantErr: true,
+		},
+		{
+			name: "positive_allowed_loopback",
+			conf: config.Destinations{Allow: []string{"127.0.0.0/8", "::1"}},
+			url:  "http://localhost:" + serverUrl.Port(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewPolicy(tt.conf)
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.DialContext = p.DialContext(&net.Dialer{})
+			client := &http.Client{Transport: transport}
+
+			response, err := client.Get(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrBlocked) {
+				t.Errorf("Expected ErrBlocked, got: %v", err)
+			}
+			if err == nil {
+				response.Body.Close()
+			}
+		})
+	}
+}
+
+func TestPolicy_Proxy(t *testing.T) {
+	proxyUrl, _ := url.Parse("http://proxy.example.com:3128")
+	tests := []struct {
+		name    string
+		conf    config.Destinations
+		url     string
+		wantErr bool
+	}{
+		{
+			name: "positive_public_address",
+			url:  "http://93.184.216.34/",
+		},
+		{
+			name:    "negative_metadata_address_error",
+			url:     "http://169.254.169.254/latest/meta-data",
+			wantErr: true,
+		},
+		{
+			name:    "negative_resolved_loopback_error",
+			url:     "http://localhost/",
+			wantErr: true,
+		},
+		{
+			name:    "negative_unresolvable_host_error",
+			url:     "http://unresolvable.invalid/",
+			wantErr: true,
+		},
+		{
+			name: "positive_unresolvable_allowed_host",
+			conf: config.Destinations{Allow: []string{"unresolvable.invalid"}},
+			url:  "http://unresolvable.invalid/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewPolicy(tt.conf)
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+
+			got, err := p.Proxy(http.ProxyURL(proxyUrl))(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Proxy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrBlocked) {
+				t.Errorf("Expected ErrBlocked, got: %v", err)
+			}
+			if err == nil && got != proxyUrl {
+				t.Errorf("Proxy() = %v, want %v", got, proxyUrl)
+			}
+		})
+	}
+}
+
+func TestPolicy_DialContext_ExemptProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	p, _ := NewPolicy(config.Destinations{})
+	dial := p.DialContext(&net.Dialer{}, ProxyAddr(serverUrl))
+	conn, err := dial(context.Background(), "tcp", serverUrl.Host)
+	if err != nil {
+		t.Fatalf("DialContext() of exempt proxy error = %v", err)
+	}
+	conn.Close()
+
+	if _, err = dial(context.Background(), "tcp", "127.0.0.1:1"); !errors.Is(err, ErrBlocked) {
+		t.Errorf("DialContext() error = %v, want %v", err, ErrBlocked)
+	}
+}
+
+func TestProxyAddr(t *testing.T) {
+	for rawUrl, want := range map[string]string{
+		"http://proxy:3128": "proxy:3128",
+		"http://proxy":      "proxy:80",
+		"https://proxy":     "proxy:443",
+		"socks5://[::1]":    "[::1]:1080",
+	} {
+		u, _ := url.Parse(rawUrl)
+		if got := ProxyAddr(u); got != want {
+			t.Errorf("ProxyAddr(%q) = %q, want %q", rawUrl, got, want)
+		}
+	}
+}
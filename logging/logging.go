@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	FormatJson   = "json"
+	FormatLogfmt = "logfmt"
+
+	RequestIdKey = "request_id"
+	FetcherIdKey = "fetcher_id"
+	RunIdKey     = "run_id"
+)
+
+func New(conf config.Log) (*logrus.Logger, error) {
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+
+	level := logrus.InfoLevel
+	if len(conf.Level) > 0 {
+		var err error
+		level, err = logrus.ParseLevel(conf.Level)
+		if err != nil {
+			return nil, err
+		}
+	}
+	logger.SetLevel(level)
+
+	switch conf.Format {
+	case FormatJson, "":
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	case FormatLogfmt:
+		logger.SetFormatter(&logrus.TextFormatter{DisableColors: true, FullTimestamp: true})
+	default:
+		return nil, fmt.Errorf("unknown log format %q", conf.Format)
+	}
+
+	return logger, nil
+}
+
+// NewId returns random id used to correlate log lines of a single request or job run
+func NewId() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "fetcher"
+
+// Metrics keeps collectors in its own registry, so every Api instance can register them independently
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	FetcherLastStatus   *prometheus.GaugeVec
+	FetcherLastSuccess  *prometheus.GaugeVec
+	FetcherLastDuration *prometheus.GaugeVec
+	FetcherRuns         *prometheus.CounterVec
+	FetcherDuration     *prometheus.HistogramVec
+
+	JobsInFlight       prometheus.Gauge
+	AddHistoryDuration prometheus.Histogram
+	AddHistoryErrors   prometheus.Counter
+
+	ApiRequests        *prometheus.CounterVec
+	ApiRequestDuration *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		FetcherLastStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_status_code",
+			Help:      "HTTP status code of the last fetch, 0 if request failed.",
+		}, []string{"fetcher_id"}),
+		FetcherLastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_success",
+			Help:      "Whether the last fetch succeeded (1) or failed (0).",
+		}, []string{"fetcher_id"}),
+		FetcherLastDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_duration_seconds",
+			Help:      "Duration of the last fetch.",
+		}, []string{"fetcher_id"}),
+		FetcherRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "runs_total",
+			Help:      "Number of fetches by result.",
+		}, []string{"fetcher_id", "result"}),
+		FetcherDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "duration_seconds",
+			Help:      "Duration of fetches.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"fetcher_id"}),
+		JobsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "jobs_in_flight",
+			Help:      "Number of fetch jobs currently running.",
+		}),
+		AddHistoryDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "add_history_duration_seconds",
+			Help:      "Latency of storing fetch history.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		AddHistoryErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "add_history_errors_total",
+			Help:      "Number of failed attempts to store fetch history.",
+		}),
+		ApiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_requests_total",
+			Help:      "Number of API requests by route and status code.",
+		}, []string{"method", "route", "status"}),
+		ApiRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "api_request_duration_seconds",
+			Help:      "Latency of API requests by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+
+	m.Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.FetcherLastStatus,
+		m.FetcherLastSuccess,
+		m.FetcherLastDuration,
+		m.FetcherRuns,
+		m.FetcherDuration,
+		m.JobsInFlight,
+		m.AddHistoryDuration,
+		m.AddHistoryErrors,
+		m.ApiRequests,
+		m.ApiRequestDuration,
+	)
+
+	return m
+}
+
+// RegisterCronEntries exposes number of jobs registered in scheduler, entries is called on every scrape
+func (m *Metrics) RegisterCronEntries(entries func() int) {
+	m.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cron_entries",
+		Help:      "Number of jobs registered in the scheduler.",
+	}, func() float64 {
+		return float64(entries())
+	}))
+}
+
+func (m *Metrics) ObserveFetch(fetcherId int, statusCode int, duration float64, success bool) {
+	id := strconv.Itoa(fetcherId)
+	result, successValue := "failure", 0.0
+	if success {
+		result, successValue = "success", 1.0
+	}
+
+	m.FetcherLastStatus.WithLabelValues(id).Set(float64(statusCode))
+	m.FetcherLastSuccess.WithLabelValues(id).Set(successValue)
+	m.FetcherLastDuration.WithLabelValues(id).Set(duration)
+	m.FetcherRuns.WithLabelValues(id, result).Inc()
+	m.FetcherDuration.WithLabelValues(id).Observe(duration)
+}
+
+// DeleteFetcher removes series of deleted fetcher, so it is no longer exported
+func (m *Metrics) DeleteFetcher(fetcherId int) {
+	id := strconv.Itoa(fetcherId)
+
+	m.FetcherLastStatus.DeleteLabelValues(id)
+	m.FetcherLastSuccess.DeleteLabelValues(id)
+	m.FetcherLastDuration.DeleteLabelValues(id)
+	m.FetcherRuns.DeleteLabelValues(id, "success")
+	m.FetcherRuns.DeleteLabelValues(id, "failure")
+	m.FetcherDuration.DeleteLabelValues(id)
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
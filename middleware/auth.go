@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ApiKeyKey    = "api_key"
+	bearerPrefix = "bearer "
+)
+
+// Authenticate requires "Authorization: Bearer <key>" header with key known to lookup, which returns nil for unknown
+// or revoked keys. Found key is put in context under ApiKeyKey.
+func Authenticate(lookup func(ctx context.Context, key string) (*models.ApiKey, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.Response{Error: "missing api key"})
+			return
+		}
+
+		key, err := lookup(c.Request.Context(), strings.TrimSpace(header[len(bearerPrefix):]))
+		if err != nil {
+			_ = c.Error(err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.Response{Error: "authentication error"})
+			return
+		}
+		if key == nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.Response{Error: "invalid api key"})
+			return
+		}
+
+		c.Set(ApiKeyKey, key)
+		c.Next()
+	}
+}
+
+// Anonymous lets every request act as admin of default tenant, it replaces Authenticate when authentication is disabled
+func Anonymous() gin.HandlerFunc {
+	key := &models.ApiKey{TenantId: models.DefaultTenantId, Name: "anonymous", Scopes: []string{models.ScopeAdmin}}
+
+	return func(c *gin.Context) {
+		c.Set(ApiKeyKey, key)
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests whose api key doesn't grant scope, it has to run after Authenticate or Anonymous
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := CurrentApiKey(c)
+		if key == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.Response{Error: "missing api key"})
+			return
+		}
+		if !key.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.Response{Error: fmt.Sprintf("api key lacks %s scope", scope)})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireOperator rejects requests whose api key isn't admin key of default tenant, which manages the instance
+// and other tenants. It has to run after Authenticate or Anonymous.
+func RequireOperator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := CurrentApiKey(c)
+		if key == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.Response{Error: "missing api key"})
+			return
+		}
+		if !key.IsOperator() {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.Response{Error: "api key isn't operator key"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CurrentApiKey returns api key request was authenticated with
+func CurrentApiKey(c *gin.Context) *models.ApiKey {
+	key, _ := c.Get(ApiKeyKey)
+	apiKey, _ := key.(*models.ApiKey)
+
+	return apiKey
+}
+
+// CurrentTenant returns id of tenant owning api key request was authenticated with
+func CurrentTenant(c *gin.Context) int {
+	key := CurrentApiKey(c)
+	if key == nil {
+		return 0
+	}
+
+	return key.TenantId
+}
@@ -3,11 +3,22 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/BarTar213/bartlomiej-tarczynski/logging"
+	"github.com/BarTar213/bartlomiej-tarczynski/metrics"
 	"github.com/BarTar213/bartlomiej-tarczynski/models"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/propagation"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/semconv"
 )
 
+const RequestIdHeader = "X-Request-Id"
+
 func CheckContentLength(maxContentLength int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.ContentLength > maxContentLength {
@@ -17,3 +28,87 @@ func CheckContentLength(maxContentLength int64) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequestId takes request id from X-Request-Id header or generates new one, and exposes it in context and response
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIdHeader)
+		if len(id) == 0 || len(id) > 64 {
+			id = logging.NewId()
+		}
+
+		c.Set(logging.RequestIdKey, id)
+		c.Header(RequestIdHeader, id)
+		c.Next()
+	}
+}
+
+func Logger(l logrus.FieldLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		entry := l.WithFields(logrus.Fields{
+			logging.RequestIdKey: c.GetString(logging.RequestIdKey),
+			"method":             c.Request.Method,
+			"path":               c.Request.URL.Path,
+			"status":             c.Writer.Status(),
+			"latency":            time.Since(start).Seconds(),
+			"client_ip":          c.ClientIP(),
+		})
+		if len(c.Errors) > 0 {
+			entry = entry.WithField("errors", c.Errors.String())
+		}
+
+		switch status := c.Writer.Status(); {
+		case status >= http.StatusInternalServerError:
+			entry.Error("request")
+		case status >= http.StatusBadRequest:
+			entry.Warn("request")
+		default:
+			entry.Info("request")
+		}
+	}
+}
+
+func RequestMetrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if len(route) == 0 {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		m.ApiRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.ApiRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Tracing starts server span for every request, continuing trace from incoming headers, and puts it in request context
+func Tracing(tracer trace.Tracer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := propagation.ExtractHTTP(c.Request.Context(), global.Propagators(), c.Request.Header)
+
+		route := c.FullPath()
+		if len(route) == 0 {
+			route = "unmatched"
+		}
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", route, c.Request)...),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(status))
+		if len(c.Errors) > 0 {
+			span.SetAttribute("gin.errors", c.Errors.String())
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/gin-gonic/gin"
+)
+
+const sweepInterval = time.Minute
+
+// RateLimiter keeps token bucket per client. Every bucket holds up to burst tokens, refilled at rate per second,
+// and every request takes one token.
+type RateLimiter struct {
+	rate  float64
+	burst int
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter allows perMinute requests per minute with bursts up to burst requests, burst defaults to perMinute.
+// It returns nil, which doesn't limit anything, when perMinute isn't positive.
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = perMinute
+	}
+
+	return &RateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   burst,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// take takes token from bucket of key. It returns whether there was one, how many are left,
+// how long until the next one and how long until the bucket is full again.
+func (l *RateLimiter) take(key string) (bool, int, time.Duration, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(key)
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	return allowed, int(b.tokens), l.retryAfter(b), l.refill(float64(l.burst) - b.tokens)
+}
+
+// peek tells whether bucket of key has token without taking it and how long until it has one
+func (l *RateLimiter) peek(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(key)
+	return b.tokens >= 1, l.retryAfter(b)
+}
+
+// bucket returns bucket of key refilled up to now, l.mu has to be held
+func (l *RateLimiter) bucket(key string) *bucket {
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	return b
+}
+
+func (l *RateLimiter) retryAfter(b *bucket) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return l.refill(1 - b.tokens)
+}
+
+func (l *RateLimiter) refill(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep drops buckets which have been refilled to full, they are the same as new ones
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.refill(float64(l.burst)-b.tokens) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Proxies resolves client address of requests. X-Forwarded-For is used only for requests coming from trusted
+// proxies, otherwise clients could pick any address, and with it a fresh bucket, on their own.
+type Proxies struct {
+	trusted []*net.IPNet
+}
+
+// NewProxies trusts proxies with given IPs or CIDRs. Nil Proxies trusts nobody.
+func NewProxies(trusted []string) (*Proxies, error) {
+	p := &Proxies{}
+	for _, entry := range trusted {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+		}
+		p.trusted = append(p.trusted, ipNet)
+	}
+
+	return p, nil
+}
+
+// ClientAddress returns RemoteAddr of request. When it is trusted proxy, X-Forwarded-For is walked from the last
+// entry and the first address which isn't trusted proxy is returned, entries before it may be forged by the client.
+func (p *Proxies) ClientAddress(r *http.Request) string {
+	addr := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	if !p.isTrusted(addr) {
+		return addr
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		addr = hop
+		if !p.isTrusted(hop) {
+			break
+		}
+	}
+
+	return addr
+}
+
+func (p *Proxies) isTrusted(addr string) bool {
+	if p == nil {
+		return false
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range p.trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RateLimit limits requests per api key, or per client address for anonymous requests and bootstrap admin key.
+// GET, HEAD and OPTIONS requests are counted by read limiter and the others by write limiter, nil limiter lets
+// requests through. Responses carry RateLimit-Limit/Remaining/Reset headers, rejected ones get 429 with Retry-After.
+// It has to run after Authenticate or Anonymous, requests which fail authentication are limited by LimitAuthFailures.
+func RateLimit(read, write *RateLimiter, proxies *Proxies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter := write
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			limiter = read
+		}
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		allowed, remaining, retryAfter, reset := limiter.take(rateLimitKey(c, proxies))
+		c.Header("RateLimit-Limit", strconv.Itoa(limiter.burst))
+		c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(seconds(reset)))
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(seconds(retryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, models.Response{Error: "rate limit exceeded"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// LimitAuthFailures limits requests rejected with 401 per client address, so api keys can't be guessed.
+// Clients out of tokens get 429 before their key is even looked up. It has to run before Authenticate,
+// nil limiter lets requests through.
+func LimitAuthFailures(limiter *RateLimiter, proxies *Proxies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		key := "ip:" + proxies.ClientAddress(c.Request)
+		if allowed, retryAfter := limiter.peek(key); !allowed {
+			c.Header("Retry-After", strconv.Itoa(seconds(retryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, models.Response{Error: "too many authentication failures"})
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() == http.StatusUnauthorized {
+			limiter.take(key)
+		}
+	}
+}
+
+func rateLimitKey(c *gin.Context, proxies *Proxies) string {
+	if key := CurrentApiKey(c); key != nil && key.Id > 0 {
+		return "key:" + strconv.Itoa(key.Id)
+	}
+
+	return "ip:" + proxies.ClientAddress(c.Request)
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,289 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit(t *testing.T) {
+	type request struct {
+		method     string
+		key        *models.ApiKey
+		after      time.Duration
+		wantStatus int
+		wantHeader map[string]string
+	}
+	keyA := &models.ApiKey{Id: 1}
+	keyB := &models.ApiKey{Id: 2}
+	anonymous := &models.ApiKey{Name: "anonymous"}
+
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "positive_burst_then_reject",
+			requests: []request{
+				{method: http.MethodPost, key: keyA, wantStatus: http.StatusOK,
+					wantHeader: map[string]string{"RateLimit-Limit": "2", "RateLimit-Remaining": "1", "RateLimit-Reset": "30"}},
+				{method: http.MethodPost, key: keyA, wantStatus: http.StatusOK,
+					wantHeader: map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": "60"}},
+				{method: http.MethodPost, key: keyA, wantStatus: http.StatusTooManyRequests,
+					wantHeader: map[string]string{"RateLimit-Remaining": "0", "Retry-After": "30"}},
+			},
+		},
+		{
+			name: "positive_tokens_refilled",
+			requests: []request{
+				{method: http.MethodPost, key: keyA, wantStatus: http.StatusOK},
+				{method: http.MethodPost, key: keyA, wantStatus: http.StatusOK},
+				{method: http.MethodPost, key: keyA, after: 30 * time.Second, wantStatus: http.StatusOK},
+				{method: http.MethodPost, key: keyA, wantStatus: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "positive_separate_read_and_write_limits",
+			requests: []request{
+				{method: http.MethodPost, key: keyA, wantStatus: http.StatusOK},
+				{method: http.MethodPost, key: keyA, wantStatus: http.StatusOK},
+				{method: http.MethodGet, key: keyA, wantStatus: http.StatusOK,
+					wantHeader: map[string]string{"RateLimit-Limit": "3", "RateLimit-Remaining": "2"}},
+			},
+		},
+		{
+			name: "positive_separate_keys",
+			requests: []request{
+				{method: http.MethodPost, key: keyA, wantStatus: http.StatusOK},
+				{method: http.MethodPost, key: keyA, wantStatus: http.StatusOK},
+				{method: http.MethodPost, key: keyB, wantStatus: http.StatusOK},
+			},
+		},
+		{
+			name: "positive_anonymous_by_ip",
+			requests: []request{
+				{method: http.MethodPost, key: anonymous, wantStatus: http.StatusOK},
+				{method: http.MethodPost, key: nil, wantStatus: http.StatusOK},
+				{method: http.MethodPost, key: anonymous, wantStatus: http.StatusTooManyRequests},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1600000000, 0)
+			read := NewRateLimiter(60, 3)
+			write := NewRateLimiter(2, 0)
+			read.now = func() time.Time { return now }
+			write.now = func() time.Time { return now }
+
+			for i, r := range tt.requests {
+				now = now.Add(r.after)
+				key := r.key
+
+				gin.SetMode(gin.ReleaseMode)
+				router := gin.New()
+				router.Use(func(c *gin.Context) {
+					if key != nil {
+						c.Set(ApiKeyKey, key)
+					}
+				}, RateLimit(read, write, nil))
+				router.Any("/", func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest(r.method, "/", nil)
+				req.RemoteAddr = "192.0.2.1:1234"
+				router.ServeHTTP(w, req)
+
+				if w.Code != r.wantStatus {
+					t.Fatalf("Request %d: expected status %d, got %d", i, r.wantStatus, w.Code)
+				}
+				for header, want := range r.wantHeader {
+					if got := w.Header().Get(header); got != want {
+						t.Errorf("Request %d: expected %s %q, got %q", i, header, want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	if limiter := NewRateLimiter(0, 10); limiter != nil {
+		t.Fatalf("Expected nil limiter for zero rate")
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(RateLimit(nil, nil, nil))
+	router.POST("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/", nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || len(w.Header().Get("RateLimit-Limit")) > 0 {
+			t.Fatalf("Expected unlimited request, got status %d and headers %v", w.Code, w.Header())
+		}
+	}
+}
+
+func TestProxies_ClientAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		trusted   []string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{
+			name:   "positive_remote_addr",
+			remote: "192.0.2.1:1234",
+			want:   "192.0.2.1",
+		},
+		{
+			name:      "positive_forwarded_ignored_without_trusted_proxies",
+			remote:    "192.0.2.1:1234",
+			forwarded: []string{"198.51.100.7"},
+			want:      "192.0.2.1",
+		},
+		{
+			name:      "positive_forwarded_ignored_from_untrusted_proxy",
+			trusted:   []string{"10.0.0.0/8"},
+			remote:    "192.0.2.1:1234",
+			forwarded: []string{"198.51.100.7"},
+			want:      "192.0.2.1",
+		},
+		{
+			name:      "positive_forwarded_from_trusted_proxy",
+			trusted:   []string{"10.0.0.1"},
+			remote:    "10.0.0.1:1234",
+			forwarded: []string{"198.51.100.7"},
+			want:      "198.51.100.7",
+		},
+		{
+			name:      "positive_forged_entries_skipped",
+			trusted:   []string{"10.0.0.0/8"},
+			remote:    "10.0.0.1:1234",
+			forwarded: []string{"203.0.113.9, 198.51.100.7", "10.0.0.2"},
+			want:      "198.51.100.7",
+		},
+		{
+			name:      "positive_invalid_entry_stops_walk",
+			trusted:   []string{"10.0.0.0/8"},
+			remote:    "10.0.0.1:1234",
+			forwarded: []string{"198.51.100.7, unknown, 10.0.0.2"},
+			want:      "10.0.0.2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxies, err := NewProxies(tt.trusted)
+			if err != nil {
+				t.Fatalf("NewProxies() error = %v", err)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := proxies.ClientAddress(req); got != tt.want {
+				t.Errorf("Expected client address %s, got: %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewProxies_Invalid(t *testing.T) {
+	for _, entry := range []string{"proxy.local", "10.0.0.0/33"} {
+		if _, err := NewProxies([]string{entry}); err == nil {
+			t.Errorf("Expected error for trusted proxy %q", entry)
+		}
+	}
+}
+
+func TestLimitAuthFailures(t *testing.T) {
+	type request struct {
+		valid      bool
+		remote     string
+		wantStatus int
+	}
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "positive_failures_rejected_before_auth",
+			requests: []request{
+				{remote: "192.0.2.1:1234", wantStatus: http.StatusUnauthorized},
+				{remote: "192.0.2.1:1234", wantStatus: http.StatusUnauthorized},
+				{valid: true, remote: "192.0.2.1:1234", wantStatus: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "positive_successes_not_counted",
+			requests: []request{
+				{valid: true, remote: "192.0.2.1:1234", wantStatus: http.StatusOK},
+				{valid: true, remote: "192.0.2.1:1234", wantStatus: http.StatusOK},
+				{valid: true, remote: "192.0.2.1:1234", wantStatus: http.StatusOK},
+				{remote: "192.0.2.1:1234", wantStatus: http.StatusUnauthorized},
+			},
+		},
+		{
+			name: "positive_separate_clients",
+			requests: []request{
+				{remote: "192.0.2.1:1234", wantStatus: http.StatusUnauthorized},
+				{remote: "192.0.2.1:1234", wantStatus: http.StatusUnauthorized},
+				{remote: "192.0.2.2:1234", wantStatus: http.StatusUnauthorized},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1600000000, 0)
+			limiter := NewRateLimiter(1, 2)
+			limiter.now = func() time.Time { return now }
+			lookup := func(ctx context.Context, key string) (*models.ApiKey, error) {
+				if key == "valid" {
+					return &models.ApiKey{Id: 1}, nil
+				}
+				return nil, nil
+			}
+
+			gin.SetMode(gin.ReleaseMode)
+			router := gin.New()
+			router.Use(LimitAuthFailures(limiter, nil), Authenticate(lookup))
+			router.GET("/", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			for i, r := range tt.requests {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = r.remote
+				req.Header.Set("Authorization", "Bearer invalid")
+				if r.valid {
+					req.Header.Set("Authorization", "Bearer valid")
+				}
+				router.ServeHTTP(w, req)
+
+				if w.Code != r.wantStatus {
+					t.Fatalf("Request %d: expected status %d, got %d", i, r.wantStatus, w.Code)
+				}
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "60" {
+					t.Errorf("Request %d: expected Retry-After 60, got %q", i, w.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package mock
 
 type PgError struct {
 	FieldCode               string
+	FieldConstraint         string
 	ErrorValue              string
 	IntegrityViolationValue bool
 }
@@ -15,8 +16,11 @@ func (e *PgError) Error() string {
 }
 
 func (e *PgError) Field(field byte) string {
-	if field == 'C' {
+	switch field {
+	case 'C':
 		return e.FieldCode
+	case 'n':
+		return e.FieldConstraint
 	}
 	return ""
 }
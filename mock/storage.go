@@ -1,76 +1,577 @@
 package mock
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
 
 	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/go-pg/pg/v10"
 )
 
 const errMsg = "example error msg"
 
 type Storage struct {
-	GetFetchersErr        bool
-	GetFetcherJobErr      bool
-	AddFetcherErr         bool
-	UpdateFetcherErr      bool
-	UpdateFetcherJobIdErr bool
-	DeleteFetcherErr      bool
-	GetHistoryErr         bool
-	AddHistoryErr         bool
+	PingErr                    bool
+	GetTenantsErr              bool
+	GetTenantErr               bool
+	AddTenantErr               bool
+	UpdateTenantErr            bool
+	GetFetchersErr             bool
+	GetFetchersBySelectorErr   bool
+	GetAllFetchersErr          bool
+	GetFetcherErr              bool
+	AddFetcherErr              bool
+	AddFetcherQuotaErr         bool
+	UpdateFetcherErr           bool
+	UpdateFetcherConflictErr   bool
+	UpdateFetcherJobIdErr      bool
+	SetFetcherPausedErr        bool
+	UpdateFetcherCertExpiryErr bool
+	GetFetcherSecretErr        bool
+	DeleteFetcherErr           bool
+	GetFetcherRevisionsErr     bool
+	GetFetcherRevisionErr      bool
+	GetHistoryErr              bool
+	AddHistoryErr              bool
+	GetStatsErr                bool
+	GetLatestHistoryErr        bool
+	GetHistorySinceErr         bool
+	GetAlertRulesErr           bool
+	AddAlertRuleErr            bool
+	DeleteAlertRuleErr         bool
+	GetActiveAlertsErr         bool
+	GetFiringAlertErr          bool
+	AddAlertErr                bool
+	ResolveAlertErr            bool
+
+	GetWebhooksErr          bool
+	GetWebhooksForEventErr  bool
+	AddWebhookErr           bool
+	DeleteWebhookErr        bool
+	GetWebhookDeliveriesErr bool
+	AddWebhookDeliveryErr   bool
+
+	GetSecretsErr    bool
+	GetSecretErr     bool
+	SetSecretErr     bool
+	DeleteSecretErr  bool
+	RewrapSecretsErr bool
+
+	GetApiKeysErr      bool
+	GetApiKeyByHashErr bool
+	AddApiKeyErr       bool
+	RevokeApiKeyErr    bool
+
+	GetAuditLogErr   bool
+	AddAuditEntryErr bool
+
+	Tenant *models.Tenant
+
+	// Fetcher is returned for every fetcher id, otherwise fetchers are looked up in Fetchers. Like in Postgres,
+	// fetchers of other tenants don't exist for the asking one, while unknown ids belong to every tenant.
+	Fetcher    *models.Fetcher
+	Fetchers   []models.Fetcher
+	Revisions  []models.FetcherRevision
+	Secret     string
+	Webhooks   []models.Webhook
+	Deliveries []models.WebhookDelivery
+	Secrets    map[string]models.Secret
+	ApiKeys    []models.ApiKey
+	AuditLog   []models.AuditEntry
+
+	// History is returned by GetLatestHistory and GetHistorySince newest first, in any order given, like Postgres
+	// orders it. FiringAlert is returned by GetFiringAlert for its rule.
+	History     []models.History
+	AlertRules  []models.AlertRule
+	FiringAlert *models.Alert
 }
 
-func (s *Storage) GetFetchers() ([]models.Fetcher, error) {
+func (s *Storage) Ping(ctx context.Context) error {
+	if s.PingErr {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func (s *Storage) GetTenants(ctx context.Context) ([]models.Tenant, error) {
+	if s.GetTenantsErr {
+		return nil, errors.New(errMsg)
+	}
+	return []models.Tenant{}, nil
+}
+
+func (s *Storage) GetTenant(ctx context.Context, id int) (*models.Tenant, error) {
+	if s.GetTenantErr {
+		return nil, errors.New(errMsg)
+	}
+	if s.Tenant != nil {
+		return s.Tenant, nil
+	}
+	return &models.Tenant{Id: id}, nil
+}
+
+func (s *Storage) AddTenant(ctx context.Context, tenant *models.Tenant) error {
+	if s.AddTenantErr {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func (s *Storage) UpdateTenant(ctx context.Context, tenant *models.Tenant) error {
+	if s.UpdateTenantErr {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+// GetFetchers returns first filter.Limit of tenant Fetchers matching filter and count of all matching ones
+// GetFetchers returns Fetchers of tenant matching filter, at most filter.Limit+1 of them as Postgres does
+func (s *Storage) GetFetchers(ctx context.Context, tenantId int, filter *models.FetcherFilter) ([]models.Fetcher, int, error) {
 	if s.GetFetchersErr {
+		return nil, 0, errors.New(errMsg)
+	}
+	fetchers := make([]models.Fetcher, 0)
+	for _, fetcher := range s.Fetchers {
+		if fetcher.TenantId == tenantId && matchesFilter(&fetcher, filter) {
+			fetchers = append(fetchers, fetcher)
+		}
+	}
+	total := len(fetchers)
+	if len(fetchers) > filter.Limit {
+		fetchers = fetchers[:filter.Limit]
+	}
+	return fetchers, total, nil
+}
+
+// matchesFilter applies filter the way Postgres does, except for last status, as the mock doesn't keep history.
+// Sort and cursor are ignored.
+func matchesFilter(fetcher *models.Fetcher, filter *models.FetcherFilter) bool {
+	contains := func(s, substr string) bool {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+	if !filter.Selector.Matches(fetcher.Labels) || !contains(fetcher.Name, filter.Name) || !contains(fetcher.Url, filter.Url) {
+		return false
+	}
+	if u, err := url.Parse(fetcher.Url); len(filter.Host) > 0 && (err != nil || !strings.EqualFold(u.Hostname(), filter.Host)) {
+		return false
+	}
+	if filter.Enabled != nil && fetcher.Paused == *filter.Enabled {
+		return false
+	}
+	if (filter.MinInterval > 0 && fetcher.Interval < filter.MinInterval) || (filter.MaxInterval > 0 && fetcher.Interval > filter.MaxInterval) {
+		return false
+	}
+
+	return true
+}
+
+func (s *Storage) GetFetchersBySelector(ctx context.Context, tenantId int, selector models.Selector) ([]models.Fetcher, error) {
+	if s.GetFetchersBySelectorErr {
+		return nil, errors.New(errMsg)
+	}
+	fetchers := make([]models.Fetcher, 0)
+	for _, fetcher := range s.Fetchers {
+		if fetcher.TenantId == tenantId && selector.Matches(fetcher.Labels) {
+			fetchers = append(fetchers, fetcher)
+		}
+	}
+	return fetchers, nil
+}
+
+func (s *Storage) GetAllFetchers(ctx context.Context) ([]models.Fetcher, error) {
+	if s.GetAllFetchersErr {
 		return nil, errors.New(errMsg)
 	}
 	return []models.Fetcher{}, nil
 }
 
-func (s *Storage) AddFetcher(fetcher *models.Fetcher) error {
+func (s *Storage) GetFetcher(ctx context.Context, tenantId, id int) (*models.Fetcher, error) {
+	if s.GetFetcherErr {
+		return nil, errors.New(errMsg)
+	}
+	return s.fetcher(tenantId, id)
+}
+
+// fetcher returns copy of fetcher with given id as seen by tenant
+func (s *Storage) fetcher(tenantId, id int) (*models.Fetcher, error) {
+	found := s.Fetcher
+	for i := range s.Fetchers {
+		if found == nil && s.Fetchers[i].Id == id {
+			found = &s.Fetchers[i]
+		}
+	}
+	if found == nil {
+		return &models.Fetcher{Id: id, TenantId: tenantId}, nil
+	}
+	if found.TenantId != tenantId {
+		return nil, pg.ErrNoRows
+	}
+	fetcher := *found
+	return &fetcher, nil
+}
+
+func (s *Storage) AddFetcher(ctx context.Context, fetcher *models.Fetcher) error {
 	if s.AddFetcherErr {
 		return errors.New(errMsg)
 	}
+	if s.AddFetcherQuotaErr {
+		return fmt.Errorf("%w: %s", models.ErrQuotaExceeded, errMsg)
+	}
+	fetcher.Revision = 1
 	return nil
 }
 
-func (s *Storage) DeleteFetcher(id int) (int, error) {
+func (s *Storage) GetFetcherSecret(ctx context.Context, fetcherId int) (string, error) {
+	if s.GetFetcherSecretErr {
+		return "", errors.New(errMsg)
+	}
+	return s.Secret, nil
+}
+
+func (s *Storage) DeleteFetcher(ctx context.Context, tenantId, id int) (int, error) {
 	if s.DeleteFetcherErr {
 		return 0, errors.New(errMsg)
 	}
-	return 0, nil
+	_, err := s.fetcher(tenantId, id)
+	return 0, err
 }
 
-func (s *Storage) GetHistory(id int) ([]models.History, error) {
+func (s *Storage) GetFetcherRevisions(ctx context.Context, tenantId, fetcherId int) ([]models.FetcherRevision, error) {
+	if s.GetFetcherRevisionsErr {
+		return nil, errors.New(errMsg)
+	}
+	if _, err := s.fetcher(tenantId, fetcherId); err != nil {
+		return nil, err
+	}
+	return []models.FetcherRevision{}, nil
+}
+
+func (s *Storage) GetFetcherRevision(ctx context.Context, tenantId, fetcherId, revision int) (*models.FetcherRevision, error) {
+	if s.GetFetcherRevisionErr {
+		return nil, errors.New(errMsg)
+	}
+	if _, err := s.fetcher(tenantId, fetcherId); err != nil {
+		return nil, err
+	}
+	for _, rev := range s.Revisions {
+		if rev.FetcherId == fetcherId && rev.Revision == revision {
+			return &rev, nil
+		}
+	}
+	return nil, pg.ErrNoRows
+}
+
+func (s *Storage) GetHistory(ctx context.Context, tenantId, id int) ([]models.History, error) {
 	if s.GetHistoryErr {
 		return nil, errors.New(errMsg)
 	}
+	if _, err := s.fetcher(tenantId, id); err != nil {
+		return nil, err
+	}
 	return []models.History{}, nil
 }
 
-func (s *Storage) UpdateFetcher(fetcher *models.Fetcher) error {
+func (s *Storage) UpdateFetcher(ctx context.Context, fetcher *models.Fetcher) error {
 	if s.UpdateFetcherErr {
 		return errors.New(errMsg)
 	}
-	return nil
+	if s.UpdateFetcherConflictErr {
+		return &PgError{FieldCode: "23505", FieldConstraint: "fetcher_revisions_pk"}
+	}
+	_, err := s.fetcher(fetcher.TenantId, fetcher.Id)
+	return err
 }
 
-func (s *Storage) UpdateFetcherJobId(fetcherId, jobId int) error {
+func (s *Storage) UpdateFetcherJobId(ctx context.Context, fetcherId, jobId int) error {
 	if s.UpdateFetcherJobIdErr {
 		return errors.New(errMsg)
 	}
 	return nil
 }
 
-func (s *Storage) AddHistory(history *models.History) error {
+func (s *Storage) SetFetcherPaused(ctx context.Context, tenantId, id int, paused bool, jobId int) error {
+	if s.SetFetcherPausedErr {
+		return errors.New(errMsg)
+	}
+	_, err := s.fetcher(tenantId, id)
+	return err
+}
+
+func (s *Storage) UpdateFetcherCertExpiry(ctx context.Context, fetcherId int, expiresAt int64) error {
+	if s.UpdateFetcherCertExpiryErr {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func (s *Storage) AddHistory(ctx context.Context, history *models.History) error {
 	if s.AddHistoryErr {
 		return errors.New(errMsg)
 	}
 	return nil
 }
 
-func (s *Storage) GetFetcherJob(id int) (int, error) {
-	if s.GetFetcherJobErr {
+func (s *Storage) GetStats(ctx context.Context, tenantId, id int, since int64) (*models.Stats, error) {
+	if s.GetStatsErr {
+		return nil, errors.New(errMsg)
+	}
+	if _, err := s.fetcher(tenantId, id); err != nil {
+		return nil, err
+	}
+	return models.NewStats(id, s.history(id, since)), nil
+}
+
+func (s *Storage) GetLatestHistory(ctx context.Context, fetcherId, limit int) ([]models.History, error) {
+	if s.GetLatestHistoryErr {
+		return nil, errors.New(errMsg)
+	}
+	history := s.history(fetcherId, 0)
+	if len(history) > limit {
+		history = history[:limit]
+	}
+	return history, nil
+}
+
+func (s *Storage) GetHistorySince(ctx context.Context, fetcherId int, since int64) ([]models.History, error) {
+	if s.GetHistorySinceErr {
+		return nil, errors.New(errMsg)
+	}
+	return s.history(fetcherId, since), nil
+}
+
+// history returns runs of fetcher created since given time, newest first
+func (s *Storage) history(fetcherId int, since int64) []models.History {
+	history := make([]models.History, 0)
+	for _, h := range s.History {
+		if h.FetcherId == fetcherId && h.CreatedAt >= since {
+			history = append(history, h)
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CreatedAt > history[j].CreatedAt
+	})
+
+	return history
+}
+
+func (s *Storage) GetAlertRules(ctx context.Context, tenantId, fetcherId int) ([]models.AlertRule, error) {
+	if s.GetAlertRulesErr {
+		return nil, errors.New(errMsg)
+	}
+	if _, err := s.fetcher(tenantId, fetcherId); err != nil {
+		return nil, err
+	}
+	rules := make([]models.AlertRule, 0)
+	for _, rule := range s.AlertRules {
+		if rule.FetcherId == fetcherId {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (s *Storage) AddAlertRule(ctx context.Context, tenantId int, rule *models.AlertRule) error {
+	if s.AddAlertRuleErr {
+		return errors.New(errMsg)
+	}
+	_, err := s.fetcher(tenantId, rule.FetcherId)
+	return err
+}
+
+func (s *Storage) DeleteAlertRule(ctx context.Context, tenantId, fetcherId, id int) error {
+	if s.DeleteAlertRuleErr {
+		return errors.New(errMsg)
+	}
+	_, err := s.fetcher(tenantId, fetcherId)
+	return err
+}
+
+func (s *Storage) GetActiveAlerts(ctx context.Context, tenantId int) ([]models.Alert, error) {
+	if s.GetActiveAlertsErr {
+		return nil, errors.New(errMsg)
+	}
+	return []models.Alert{}, nil
+}
+
+func (s *Storage) GetFiringAlert(ctx context.Context, ruleId int) (*models.Alert, error) {
+	if s.GetFiringAlertErr {
+		return nil, errors.New(errMsg)
+	}
+	if s.FiringAlert == nil || s.FiringAlert.RuleId != ruleId {
+		return nil, nil
+	}
+	alert := *s.FiringAlert
+	return &alert, nil
+}
+
+func (s *Storage) AddAlert(ctx context.Context, alert *models.Alert) error {
+	if s.AddAlertErr {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func (s *Storage) ResolveAlert(ctx context.Context, alert *models.Alert) error {
+	if s.ResolveAlertErr {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func (s *Storage) GetWebhooks(ctx context.Context, tenantId int) ([]models.Webhook, error) {
+	if s.GetWebhooksErr {
+		return nil, errors.New(errMsg)
+	}
+	return []models.Webhook{}, nil
+}
+
+func (s *Storage) GetWebhooksForEvent(ctx context.Context, tenantId int, event string) ([]models.Webhook, error) {
+	if s.GetWebhooksForEventErr {
+		return nil, errors.New(errMsg)
+	}
+	return s.Webhooks, nil
+}
+
+func (s *Storage) AddWebhook(ctx context.Context, webhook *models.Webhook) error {
+	if s.AddWebhookErr {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, tenantId, id int) error {
+	if s.DeleteWebhookErr {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func (s *Storage) GetWebhookDeliveries(ctx context.Context, tenantId, webhookId int) ([]models.WebhookDelivery, error) {
+	if s.GetWebhookDeliveriesErr {
+		return nil, errors.New(errMsg)
+	}
+	return []models.WebhookDelivery{}, nil
+}
+
+func (s *Storage) AddWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if s.AddWebhookDeliveryErr {
+		return errors.New(errMsg)
+	}
+	s.Deliveries = append(s.Deliveries, *delivery)
+	return nil
+}
+
+func (s *Storage) GetSecrets(ctx context.Context, tenantId int) ([]models.Secret, error) {
+	if s.GetSecretsErr {
+		return nil, errors.New(errMsg)
+	}
+	return []models.Secret{}, nil
+}
+
+func (s *Storage) GetSecret(ctx context.Context, tenantId int, name string) (*models.Secret, error) {
+	secret, ok := s.Secrets[name]
+	if s.GetSecretErr || !ok || secret.TenantId != tenantId {
+		return nil, errors.New(errMsg)
+	}
+	return &secret, nil
+}
+
+func (s *Storage) SetSecret(ctx context.Context, secret *models.Secret) error {
+	if s.SetSecretErr {
+		return errors.New(errMsg)
+	}
+	if s.Secrets == nil {
+		s.Secrets = make(map[string]models.Secret)
+	}
+	s.Secrets[secret.Name] = *secret
+	return nil
+}
+
+func (s *Storage) DeleteSecret(ctx context.Context, tenantId int, name string) error {
+	if s.DeleteSecretErr {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func (s *Storage) RewrapSecrets(ctx context.Context, rewrap func(envelope string) (string, error)) (int, error) {
+	if s.RewrapSecretsErr {
 		return 0, errors.New(errMsg)
 	}
-	return 0, nil
+	changed := 0
+	for name, secret := range s.Secrets {
+		envelope, err := rewrap(secret.Envelope)
+		if err != nil {
+			return 0, err
+		}
+		if envelope != secret.Envelope {
+			secret.Envelope = envelope
+			s.Secrets[name] = secret
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (s *Storage) GetApiKeys(ctx context.Context, tenantId int) ([]models.ApiKey, error) {
+	if s.GetApiKeysErr {
+		return nil, errors.New(errMsg)
+	}
+	return []models.ApiKey{}, nil
+}
+
+func (s *Storage) GetApiKeyByHash(ctx context.Context, hash string) (*models.ApiKey, error) {
+	if s.GetApiKeyByHashErr {
+		return nil, errors.New(errMsg)
+	}
+	for _, key := range s.ApiKeys {
+		if key.Hash == hash && key.RevokedAt == nil {
+			return &key, nil
+		}
+	}
+	return nil, pg.ErrNoRows
+}
+
+func (s *Storage) AddApiKey(ctx context.Context, key *models.ApiKey) error {
+	if s.AddApiKeyErr {
+		return errors.New(errMsg)
+	}
+	s.ApiKeys = append(s.ApiKeys, *key)
+	return nil
+}
+
+func (s *Storage) RevokeApiKey(ctx context.Context, tenantId, id int, revokedAt int64) error {
+	if s.RevokeApiKeyErr {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func (s *Storage) GetAuditLog(ctx context.Context, tenantId int, filter *models.AuditFilter) ([]models.AuditEntry, error) {
+	if s.GetAuditLogErr {
+		return nil, errors.New(errMsg)
+	}
+	return []models.AuditEntry{}, nil
+}
+
+func (s *Storage) AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	if s.AddAuditEntryErr {
+		return errors.New(errMsg)
+	}
+	s.AuditLog = append(s.AuditLog, *entry)
+	return nil
+}
+
+// RunInTransaction rolls back only audit log, other calls keep their effect like outside of transaction
+func (s *Storage) RunInTransaction(ctx context.Context, fn func(s storage.Storage) error) error {
+	audited := len(s.AuditLog)
+	if err := fn(s); err != nil {
+		s.AuditLog = s.AuditLog[:audited]
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,52 @@
+package models
+
+import "errors"
+
+const (
+	RuleConsecutiveFailures = "consecutive_failures"
+	RuleLatency             = "latency"
+
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// AlertRule describes condition under which alert for a fetcher is fired.
+// Failures is used by consecutive_failures rule, Latency (in seconds) and Minutes by latency rule.
+type AlertRule struct {
+	Id        int     `json:"id"`
+	FetcherId int     `json:"fetcher_id"`
+	Type      string  `json:"type"`
+	Failures  int     `json:"failures,omitempty"`
+	Latency   float64 `json:"latency,omitempty"`
+	Minutes   int     `json:"minutes,omitempty"`
+}
+
+func (r *AlertRule) Validate() error {
+	switch r.Type {
+	case RuleConsecutiveFailures:
+		if r.Failures <= 0 {
+			return errors.New("failures must be greater than 0")
+		}
+	case RuleLatency:
+		if r.Latency <= 0 {
+			return errors.New("latency must be greater than 0")
+		}
+		if r.Minutes <= 0 {
+			return errors.New("minutes must be greater than 0")
+		}
+	default:
+		return errors.New("invalid rule type")
+	}
+
+	return nil
+}
+
+type Alert struct {
+	Id         int    `json:"id"`
+	RuleId     int    `json:"rule_id"`
+	FetcherId  int    `json:"fetcher_id"`
+	State      string `json:"state"`
+	Message    string `json:"message"`
+	FiredAt    int64  `json:"fired_at"`
+	ResolvedAt *int64 `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "testing"
+
+func TestAlertRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    AlertRule
+		wantErr bool
+	}{
+		{
+			name: "positive_validate_consecutive_failures",
+			rule: AlertRule{
+				Type:     RuleConsecutiveFailures,
+				Failures: 3,
+			},
+			wantErr: false,
+		},
+		{
+			name: "positive_validate_latency",
+			rule: AlertRule{
+				Type:    RuleLatency,
+				Latency: 0.5,
+				Minutes: 10,
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative_validate_invalid_type_error",
+			rule: AlertRule{
+				Type: "abc",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative_validate_invalid_failures_error",
+			rule: AlertRule{
+				Type: RuleConsecutiveFailures,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative_validate_invalid_latency_error",
+			rule: AlertRule{
+				Type:    RuleLatency,
+				Minutes: 10,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative_validate_invalid_minutes_error",
+			rule: AlertRule{
+				Type:    RuleLatency,
+				Latency: 0.5,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
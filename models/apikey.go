@@ -0,0 +1,104 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const (
+	ScopeFetchersRead  = "fetchers:read"
+	ScopeFetchersWrite = "fetchers:write"
+	ScopeHistoryRead   = "history:read"
+	ScopeAdmin         = "admin"
+
+	apiKeyPrefix       = "fk_"
+	apiKeyPrefixLength = 8
+)
+
+var Scopes = map[string]bool{
+	ScopeFetchersRead:  true,
+	ScopeFetchersWrite: true,
+	ScopeHistoryRead:   true,
+	ScopeAdmin:         true,
+}
+
+// ApiKey authenticates API requests. Only SHA-256 hash of the key is stored, the key itself is returned
+// once, when it's created. Prefix is kept to tell keys apart.
+type ApiKey struct {
+	Id        int      `json:"id"`
+	TenantId  int      `json:"tenant_id"`
+	Name      string   `json:"name"`
+	Prefix    string   `json:"prefix"`
+	Hash      string   `json:"-"`
+	Scopes    []string `json:"scopes" pg:",array"`
+	CreatedAt int64    `json:"created_at"`
+	RevokedAt *int64   `json:"revoked_at,omitempty"`
+	Key       string   `json:"key,omitempty" pg:"-"`
+}
+
+func (k *ApiKey) Validate() error {
+	if len(k.Name) == 0 {
+		return errors.New("api key name can't be empty")
+	}
+
+	if len(k.Scopes) == 0 {
+		return errors.New("api key needs at least one scope")
+	}
+	for _, scope := range k.Scopes {
+		if !Scopes[scope] {
+			return fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+
+	return nil
+}
+
+// HasScope reports whether key grants given scope, admin grants all of them
+func (k *ApiKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Actor identifies key in audit log, stored keys by their prefix
+func (k *ApiKey) Actor() string {
+	if len(k.Prefix) > 0 {
+		return k.Prefix
+	}
+
+	return k.Name
+}
+
+// IsOperator tells whether key is admin key of default tenant, which may manage other tenants
+func (k *ApiKey) IsOperator() bool {
+	return k.TenantId == DefaultTenantId && k.HasScope(ScopeAdmin)
+}
+
+// Generate sets new random key together with its prefix and hash
+func (k *ApiKey) Generate() error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+
+	k.Key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	k.Prefix = k.Key[:apiKeyPrefixLength]
+	k.Hash = HashApiKey(k.Key)
+
+	return nil
+}
+
+// HashApiKey returns hex encoded SHA-256 of key, keys are random, so they need no salt or slow hash
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApiKey_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     ApiKey
+		wantErr bool
+	}{
+		{
+			name:    "positive_validate_api_key",
+			key:     ApiKey{Name: "ci", Scopes: []string{ScopeFetchersRead, ScopeHistoryRead}},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_empty_name_error",
+			key:     ApiKey{Scopes: []string{ScopeAdmin}},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_no_scopes_error",
+			key:     ApiKey{Name: "ci"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_unknown_scope_error",
+			key:     ApiKey{Name: "ci", Scopes: []string{"root"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.key.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApiKey_HasScope(t *testing.T) {
+	reader := &ApiKey{Scopes: []string{ScopeFetchersRead}}
+	if !reader.HasScope(ScopeFetchersRead) || reader.HasScope(ScopeFetchersWrite) {
+		t.Errorf("Expected reader to have only %s scope", ScopeFetchersRead)
+	}
+
+	admin := &ApiKey{Scopes: []string{ScopeAdmin}}
+	if !admin.HasScope(ScopeFetchersWrite) || !admin.HasScope(ScopeHistoryRead) {
+		t.Errorf("Expected admin to have all scopes")
+	}
+}
+
+func TestApiKey_Generate(t *testing.T) {
+	first, second := &ApiKey{}, &ApiKey{}
+	if err := first.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	_ = second.Generate()
+
+	if !strings.HasPrefix(first.Key, "fk_") || !strings.HasPrefix(first.Key, first.Prefix) {
+		t.Errorf("Unexpected key %q with prefix %q", first.Key, first.Prefix)
+	}
+	if first.Hash != HashApiKey(first.Key) || strings.Contains(first.Hash, first.Key) {
+		t.Errorf("Unexpected hash %q", first.Hash)
+	}
+	if first.Key == second.Key {
+		t.Errorf("Expected random keys")
+	}
+}
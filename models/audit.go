@@ -0,0 +1,44 @@
+package models
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditPause   = "pause"
+	AuditResume  = "resume"
+	AuditRevoke  = "revoke"
+	AuditRotate  = "rotate"
+	AuditRestore = "restore"
+
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+// AuditEntry records change of configuration made through the API. Before and After hold JSON of changed
+// resource, without secrets, when its state is known. FetcherId is set for fetchers and their alert rules.
+// Actor is a key prefix, which several keys may share, so stored keys are identified by ApiKeyId as well.
+type AuditEntry struct {
+	tableName struct{} `pg:"audit_log"`
+
+	Id         int         `json:"id"`
+	TenantId   int         `json:"-"`
+	Actor      string      `json:"actor"`
+	ApiKeyId   *int        `json:"api_key_id,omitempty"`
+	Action     string      `json:"action"`
+	Resource   string      `json:"resource"`
+	ResourceId string      `json:"resource_id,omitempty"`
+	FetcherId  *int        `json:"fetcher_id,omitempty"`
+	Before     interface{} `json:"before,omitempty"`
+	After      interface{} `json:"after,omitempty"`
+	CreatedAt  int64       `json:"created_at"`
+}
+
+// AuditFilter narrows audit log down to entries of fetcher, made by actor or created in [Since, Until] range,
+// zero values don't filter
+type AuditFilter struct {
+	FetcherId int
+	Actor     string
+	Since     int64
+	Until     int64
+	Limit     int
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+)
+
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthApiKey = "api_key"
+	AuthOAuth2 = "oauth2"
+)
+
+// Auth describes credentials sent with fetcher requests. Secret holds password (basic), token (bearer),
+// key (api_key) or client secret (oauth2); it is stored apart from fetcher and never returned by the API.
+type Auth struct {
+	Type     string   `json:"type"`
+	Username string   `json:"username,omitempty"`
+	Header   string   `json:"header,omitempty"`
+	TokenUrl string   `json:"token_url,omitempty"`
+	ClientId string   `json:"client_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	Secret   string   `json:"secret,omitempty"`
+}
+
+func (a *Auth) Validate() error {
+	switch a.Type {
+	case AuthBasic:
+		if len(a.Username) == 0 {
+			return errors.New("auth username can't be empty")
+		}
+	case AuthBearer:
+	case AuthApiKey:
+		if len(a.Header) == 0 {
+			return errors.New("auth header can't be empty")
+		}
+	case AuthOAuth2:
+		if len(a.ClientId) == 0 {
+			return errors.New("auth client id can't be empty")
+		}
+		if _, err := url.ParseRequestURI(a.TokenUrl); err != nil {
+			return errors.New("invalid auth token url")
+		}
+	default:
+		return errors.New("invalid auth type")
+	}
+
+	return nil
+}
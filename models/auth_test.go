@@ -0,0 +1,67 @@
+package models
+
+import "testing"
+
+func TestAuth_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    Auth
+		wantErr bool
+	}{
+		{
+			name:    "positive_validate_basic",
+			auth:    Auth{Type: AuthBasic, Username: "user", Secret: "pass"},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_basic_no_username_error",
+			auth:    Auth{Type: AuthBasic},
+			wantErr: true,
+		},
+		{
+			name:    "positive_validate_bearer",
+			auth:    Auth{Type: AuthBearer, Secret: "token"},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_api_key_no_header_error",
+			auth:    Auth{Type: AuthApiKey, Secret: "key"},
+			wantErr: true,
+		},
+		{
+			name:    "positive_validate_oauth2",
+			auth:    Auth{Type: AuthOAuth2, TokenUrl: "https://auth.example.com/token", ClientId: "fetcher"},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_oauth2_token_url_error",
+			auth:    Auth{Type: AuthOAuth2, TokenUrl: "token", ClientId: "fetcher"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_type_error",
+			auth:    Auth{Type: "digest"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.auth.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetcher_TakeSecret(t *testing.T) {
+	f := &Fetcher{Auth: &Auth{Type: AuthBearer, Secret: "token"}}
+	if got := f.TakeSecret(); got != "token" {
+		t.Errorf("TakeSecret() = %q, want %q", got, "token")
+	}
+	if f.Auth.Secret != "" {
+		t.Errorf("Expected secret to be removed from fetcher")
+	}
+	if got := (&Fetcher{}).TakeSecret(); got != "" {
+		t.Errorf("TakeSecret() without auth = %q, want empty", got)
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"crypto/x509"
+	"time"
+)
+
+// Certificate is a summary of a peer certificate presented by fetched server
+type Certificate struct {
+	Subject  string   `json:"subject"`
+	Issuer   string   `json:"issuer"`
+	DnsNames []string `json:"dns_names,omitempty"`
+	NotAfter int64    `json:"not_after"`
+}
+
+func NewCertificate(c *x509.Certificate) Certificate {
+	return Certificate{
+		Subject:  c.Subject.String(),
+		Issuer:   c.Issuer.String(),
+		DnsNames: c.DNSNames,
+		NotAfter: c.NotAfter.Unix(),
+	}
+}
+
+// CertExpiresAt returns the earliest expiry in the chain, as any expired certificate breaks it
+func CertExpiresAt(chain []Certificate) *int64 {
+	var expiresAt *int64
+	for i := range chain {
+		if expiresAt == nil || chain[i].NotAfter < *expiresAt {
+			expiresAt = &chain[i].NotAfter
+		}
+	}
+
+	return expiresAt
+}
+
+// CertExpiring tells whether certificate expiring at given unix time is within warning window from now
+func CertExpiring(expiresAt int64, now time.Time, window time.Duration) bool {
+	return time.Unix(expiresAt, 0).Before(now.Add(window))
+}
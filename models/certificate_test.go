@@ -0,0 +1,81 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCertExpiresAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		chain []Certificate
+		want  *int64
+	}{
+		{
+			name:  "positive_no_certificates",
+			chain: nil,
+			want:  nil,
+		},
+		{
+			name: "positive_earliest_in_chain",
+			chain: []Certificate{
+				{Subject: "CN=example.com", NotAfter: 300},
+				{Subject: "CN=Intermediate", NotAfter: 200},
+				{Subject: "CN=Root", NotAfter: 900},
+			},
+			want: int64Pointer(200),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CertExpiresAt(tt.chain); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CertExpiresAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetcher_SetCertWarning(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	window := 14 * 24 * time.Hour
+	tests := []struct {
+		name          string
+		certExpiresAt *int64
+		want          bool
+	}{
+		{
+			name:          "positive_no_certificate",
+			certExpiresAt: nil,
+			want:          false,
+		},
+		{
+			name:          "positive_expires_after_window",
+			certExpiresAt: int64Pointer(now.Add(30 * 24 * time.Hour).Unix()),
+			want:          false,
+		},
+		{
+			name:          "positive_expires_within_window",
+			certExpiresAt: int64Pointer(now.Add(3 * 24 * time.Hour).Unix()),
+			want:          true,
+		},
+		{
+			name:          "positive_already_expired",
+			certExpiresAt: int64Pointer(now.Add(-time.Hour).Unix()),
+			want:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Fetcher{CertExpiresAt: tt.certExpiresAt}
+			f.SetCertWarning(now, window)
+			if f.CertWarning != tt.want {
+				t.Errorf("SetCertWarning() warning = %t, want %t", f.CertWarning, tt.want)
+			}
+		})
+	}
+}
+
+func int64Pointer(i int64) *int64 {
+	return &i
+}
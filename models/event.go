@@ -0,0 +1,44 @@
+package models
+
+const (
+	EventFetchFailed    = "fetch.failed"
+	EventContentChanged = "content.changed"
+	EventAlertFired     = "alert.fired"
+	EventAlertResolved  = "alert.resolved"
+	EventFetcherCreated = "fetcher.created"
+	EventFetcherDeleted = "fetcher.deleted"
+	EventCertExpiring   = "cert.expiring"
+)
+
+var EventTypes = []string{
+	EventFetchFailed,
+	EventContentChanged,
+	EventAlertFired,
+	EventAlertResolved,
+	EventFetcherCreated,
+	EventFetcherDeleted,
+	EventCertExpiring,
+}
+
+type Event struct {
+	Type       string  `json:"type"`
+	TenantId   int     `json:"-"`
+	FetcherId  int     `json:"fetcher_id"`
+	Url        string  `json:"url,omitempty"`
+	Time       int64   `json:"time"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Duration   float64 `json:"duration,omitempty"`
+	Error      *string `json:"error,omitempty"`
+	Alert      *Alert  `json:"alert,omitempty"`
+
+	CertExpiresAt *int64 `json:"cert_expires_at,omitempty"`
+}
+
+func IsEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
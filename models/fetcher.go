@@ -2,17 +2,78 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
 	"net/url"
+	"strings"
+	"time"
 )
 
+// ProxyDirect makes fetcher bypass global proxy
+const ProxyDirect = "direct"
+
+// redactedPath replaces path and query of chat webhook urls, which carry their credentials
+const redactedPath = "/xxxxx"
+
+const (
+	maxNameLength        = 128
+	maxDescriptionLength = 1024
+)
+
+var proxySchemes = map[string]bool{"http": true, "https": true, "socks5": true}
+
 type Fetcher struct {
-	Id       int    `json:"id"`
-	Url      string `json:"url"`
-	Interval int    `json:"interval"`
-	JobId    int    `json:"-"`
+	Id              int      `json:"id"`
+	TenantId        int      `json:"tenant_id"`
+	Name            string   `json:"name,omitempty"`
+	Description     string   `json:"description,omitempty"`
+	Url             string   `json:"url"`
+	Interval        int      `json:"interval"`
+	Method          string   `json:"method,omitempty"`
+	JobId           int      `json:"-"`
+	Emails          []string `json:"emails" pg:",array"`
+	SlackWebhookUrl string   `json:"slack_webhook_url,omitempty"`
+	TeamsWebhookUrl string   `json:"teams_webhook_url,omitempty"`
+
+	// Labels are free-form key/value pairs, which select fetchers in listing and bulk operations
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Url, Headers and Body may contain ${secret:name} references resolved on every run
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+
+	Tls      *TlsConfig      `json:"tls,omitempty"`
+	Auth     *Auth           `json:"auth,omitempty"`
+	Redirect *RedirectPolicy `json:"redirect,omitempty"`
+
+	// Proxy overrides global proxy with http, https or socks5 url, or ProxyDirect to bypass it
+	Proxy string `json:"proxy,omitempty"`
+
+	// Paused fetchers have no job registered, it's changed only by pause and resume endpoints after creation
+	Paused bool `json:"paused"`
+
+	// Revision is number of the stored definition, it's increased by every update and restore
+	Revision int `json:"revision"`
+
+	// CertExpiresAt is updated by worker from the latest TLS handshake, CertWarning is computed when fetcher is returned
+	CertExpiresAt *int64 `json:"cert_expires_at,omitempty"`
+	CertWarning   bool   `json:"cert_warning,omitempty" pg:"-"`
 }
 
 func (f *Fetcher) Validate() error {
+	if len(f.Name) > maxNameLength {
+		return fmt.Errorf("name can't be longer than %d characters", maxNameLength)
+	}
+
+	if len(f.Description) > maxDescriptionLength {
+		return fmt.Errorf("description can't be longer than %d characters", maxDescriptionLength)
+	}
+
+	if err := ValidateLabels(f.Labels); err != nil {
+		return err
+	}
+
 	if len(f.Url) == 0 {
 		return errors.New("url can't be empty")
 	}
@@ -26,16 +87,168 @@ func (f *Fetcher) Validate() error {
 		return errors.New("invalid url")
 	}
 
+	switch f.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return fmt.Errorf("invalid method %q", f.Method)
+	}
+
+	for name := range f.Headers {
+		if len(name) == 0 || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("invalid header %q", name)
+		}
+	}
+
+	for _, webhook := range []string{f.SlackWebhookUrl, f.TeamsWebhookUrl} {
+		if len(webhook) == 0 {
+			continue
+		}
+		u, err := url.ParseRequestURI(webhook)
+		if err != nil || u.Scheme != "https" {
+			return fmt.Errorf("invalid webhook url %q", webhook)
+		}
+		if isRedactedUrl(webhook) {
+			return errors.New("redacted webhook url doesn't match stored one")
+		}
+	}
+
+	if f.Tls != nil {
+		if err = f.Tls.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if f.Auth != nil {
+		if err = f.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if f.Redirect != nil {
+		if err = f.Redirect.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if len(f.Proxy) > 0 && f.Proxy != ProxyDirect {
+		// secret references are not valid in user info, so they are replaced before parsing
+		u, err := url.Parse(SecretReference.ReplaceAllString(f.Proxy, "secret"))
+		if err != nil || len(u.Host) == 0 || !proxySchemes[u.Scheme] {
+			return errors.New("proxy must be direct or http, https or socks5 url")
+		}
+	}
+
+	for _, email := range f.Emails {
+		if _, err = mail.ParseAddress(email); err != nil {
+			return fmt.Errorf("invalid email %q", email)
+		}
+	}
+
 	return nil
 }
 
+// RequestMethod returns HTTP method of fetcher request, GET if not set
+func (f *Fetcher) RequestMethod() string {
+	if len(f.Method) == 0 {
+		return http.MethodGet
+	}
+
+	return f.Method
+}
+
+// RedactWebhooks masks path and query of chat webhook urls. Urls referencing secrets are kept,
+// they don't contain the credentials themselves.
+func (f *Fetcher) RedactWebhooks() {
+	f.SlackWebhookUrl = redactUrl(f.SlackWebhookUrl)
+	f.TeamsWebhookUrl = redactUrl(f.TeamsWebhookUrl)
+}
+
+// KeepWebhooks replaces redacted chat webhook urls with the stored ones of before,
+// so fetcher read from api can be sent back unchanged
+func (f *Fetcher) KeepWebhooks(before *Fetcher) {
+	if isRedactedUrl(f.SlackWebhookUrl) && f.SlackWebhookUrl == redactUrl(before.SlackWebhookUrl) {
+		f.SlackWebhookUrl = before.SlackWebhookUrl
+	}
+	if isRedactedUrl(f.TeamsWebhookUrl) && f.TeamsWebhookUrl == redactUrl(before.TeamsWebhookUrl) {
+		f.TeamsWebhookUrl = before.TeamsWebhookUrl
+	}
+}
+
+// RestoreWebhooks gives fetcher restored from revision chat webhook urls of current one, as revisions don't hold
+// their credentials. Urls referencing secrets carry none, so they are restored as they were.
+func (f *Fetcher) RestoreWebhooks(current *Fetcher) {
+	if !SecretReference.MatchString(f.SlackWebhookUrl) {
+		f.SlackWebhookUrl = current.SlackWebhookUrl
+	}
+	if !SecretReference.MatchString(f.TeamsWebhookUrl) {
+		f.TeamsWebhookUrl = current.TeamsWebhookUrl
+	}
+}
+
+func redactUrl(rawUrl string) string {
+	if len(rawUrl) == 0 || SecretReference.MatchString(rawUrl) {
+		return rawUrl
+	}
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return redactedPath
+	}
+
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: redactedPath}).String()
+}
+
+func isRedactedUrl(rawUrl string) bool {
+	u, err := url.Parse(rawUrl)
+	return err == nil && u.Path == redactedPath && len(u.RawQuery) == 0
+}
+
 func (f *Fetcher) Reset() {
 	f.Id = 0
+	f.TenantId = 0
+	f.Name = ""
+	f.Description = ""
 	f.Url = ""
 	f.Interval = 0
+	f.Method = ""
+	f.Headers = nil
+	f.Body = ""
 	f.JobId = 0
+	f.Emails = nil
+	f.SlackWebhookUrl = ""
+	f.TeamsWebhookUrl = ""
+	f.Labels = nil
+	f.Tls = nil
+	f.Auth = nil
+	f.Redirect = nil
+	f.Proxy = ""
+	f.Paused = false
+	f.Revision = 0
+	f.CertExpiresAt = nil
+	f.CertWarning = false
+}
+
+// TakeSecret returns auth secret and removes it from fetcher, so it is neither stored in fetcher row nor returned
+func (f *Fetcher) TakeSecret() string {
+	if f.Auth == nil {
+		return ""
+	}
+	secret := f.Auth.Secret
+	f.Auth.Secret = ""
+
+	return secret
+}
+
+// SetCertWarning marks fetcher whose certificate expires within warning window
+func (f *Fetcher) SetCertWarning(now time.Time, window time.Duration) {
+	f.CertWarning = f.CertExpiresAt != nil && CertExpiring(*f.CertExpiresAt, now, window)
 }
 
 type Id struct {
 	Id int `json:"id"`
 }
+
+// Ids lists fetchers handled by bulk operation, Error tells why it stopped before handling the rest
+type Ids struct {
+	Ids   []int  `json:"ids"`
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	LastStatusOk     = "ok"
+	LastStatusFailed = "failed"
+	LastStatusNone   = "none"
+
+	DefaultFetcherLimit = 100
+	MaxFetcherLimit     = 1000
+
+	defaultFetcherSort = "id"
+)
+
+// FetcherSortFields are fields fetcher listing can be sorted by
+var FetcherSortFields = map[string]bool{
+	"id":       true,
+	"name":     true,
+	"url":      true,
+	"interval": true,
+}
+
+// FetcherFilter narrows fetcher listing down, zero values don't filter. Name and Url match substrings of name
+// and url and Host the url's host, all case-insensitively; fetchers have to match Selector.
+// LastStatus is LastStatusOk, LastStatusFailed, LastStatusNone for fetchers which haven't run yet, or status code
+// of the latest run. Sort is a field from FetcherSortFields, prefixed with "-" for descending order.
+type FetcherFilter struct {
+	Name        string
+	Url         string
+	Host        string
+	Selector    Selector
+	Enabled     *bool
+	LastStatus  string
+	MinInterval int
+	MaxInterval int
+	Sort        string
+	Cursor      *FetcherCursor
+	Limit       int
+}
+
+// FetcherCursor points at the last fetcher of previous page, in order given by Sort
+type FetcherCursor struct {
+	Sort     string `json:"sort"`
+	Id       int    `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Url      string `json:"url,omitempty"`
+	Interval int    `json:"interval,omitempty"`
+}
+
+func (f *FetcherFilter) Validate() error {
+	if f.Sort == "" {
+		f.Sort = defaultFetcherSort
+	}
+	if !FetcherSortFields[strings.TrimPrefix(f.Sort, "-")] {
+		return fmt.Errorf("invalid sort %q", f.Sort)
+	}
+
+	switch f.LastStatus {
+	case "", LastStatusOk, LastStatusFailed, LastStatusNone:
+	default:
+		if code, err := strconv.Atoi(f.LastStatus); err != nil || code < 100 || code > 599 {
+			return fmt.Errorf("invalid last status %q", f.LastStatus)
+		}
+	}
+
+	if f.MinInterval < 0 || f.MaxInterval < 0 || (f.MaxInterval > 0 && f.MinInterval > f.MaxInterval) {
+		return errors.New("invalid interval range")
+	}
+
+	if f.Limit <= 0 || f.Limit > MaxFetcherLimit {
+		return errors.New("invalid limit")
+	}
+
+	if f.Cursor != nil && f.Cursor.Sort != f.Sort {
+		return errors.New("cursor doesn't match sort")
+	}
+
+	return nil
+}
+
+// SortField returns field fetchers are sorted by and whether the order is descending
+func (f *FetcherFilter) SortField() (string, bool) {
+	return strings.TrimPrefix(f.Sort, "-"), strings.HasPrefix(f.Sort, "-")
+}
+
+// NextCursor returns encoded cursor of page following the one ending with last
+func (f *FetcherFilter) NextCursor(last *Fetcher) string {
+	cursor := FetcherCursor{Sort: f.Sort, Id: last.Id}
+	switch field, _ := f.SortField(); field {
+	case "name":
+		cursor.Name = last.Name
+	case "url":
+		cursor.Url = last.Url
+	case "interval":
+		cursor.Interval = last.Interval
+	}
+
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseFetcherCursor decodes cursor returned by NextCursor
+func ParseFetcherCursor(s string) (*FetcherCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	cursor := &FetcherCursor{}
+	if err = json.Unmarshal(b, cursor); err != nil || cursor.Id <= 0 {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return cursor, nil
+}
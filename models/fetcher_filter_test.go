@@ -0,0 +1,89 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFetcherFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   FetcherFilter
+		wantSort string
+		wantErr  bool
+	}{
+		{
+			name:     "positive_validate_default_sort",
+			filter:   FetcherFilter{Limit: DefaultFetcherLimit},
+			wantSort: "id",
+		},
+		{
+			name: "positive_validate_all_filters",
+			filter: FetcherFilter{
+				Url:         "example",
+				Host:        "example.com",
+				LastStatus:  "503",
+				MinInterval: 10,
+				MaxInterval: 60,
+				Sort:        "-interval",
+				Cursor:      &FetcherCursor{Sort: "-interval", Id: 4, Interval: 30},
+				Limit:       MaxFetcherLimit,
+			},
+			wantSort: "-interval",
+		},
+		{
+			name:    "negative_validate_invalid_sort_error",
+			filter:  FetcherFilter{Sort: "job_id", Limit: DefaultFetcherLimit},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_invalid_last_status_error",
+			filter:  FetcherFilter{LastStatus: "broken", Limit: DefaultFetcherLimit},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_invalid_interval_range_error",
+			filter:  FetcherFilter{MinInterval: 60, MaxInterval: 10, Limit: DefaultFetcherLimit},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_invalid_limit_error",
+			filter:  FetcherFilter{Limit: MaxFetcherLimit + 1},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_cursor_of_other_sort_error",
+			filter:  FetcherFilter{Sort: "url", Cursor: &FetcherCursor{Sort: "id", Id: 4}, Limit: DefaultFetcherLimit},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tt.filter.Sort != tt.wantSort {
+				t.Errorf("Validate() sort = %q, want %q", tt.filter.Sort, tt.wantSort)
+			}
+		})
+	}
+}
+
+func TestFetcherFilter_NextCursor(t *testing.T) {
+	filter := &FetcherFilter{Sort: "-url"}
+	last := &Fetcher{Id: 7, Url: "https://example.com/a", Interval: 30}
+
+	cursor, err := ParseFetcherCursor(filter.NextCursor(last))
+	if err != nil {
+		t.Fatalf("ParseFetcherCursor() error = %v", err)
+	}
+	want := &FetcherCursor{Sort: "-url", Id: 7, Url: "https://example.com/a"}
+	if !reflect.DeepEqual(cursor, want) {
+		t.Errorf("ParseFetcherCursor() = %+v, want %+v", cursor, want)
+	}
+
+	if _, err = ParseFetcherCursor("not a cursor"); err == nil {
+		t.Errorf("ParseFetcherCursor() expected error for invalid cursor")
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -17,6 +18,9 @@ func TestFetcher_Reset(t *testing.T) {
 		Url      string
 		Interval int
 		JobId    int
+		Emails          []string
+		SlackWebhookUrl string
+		TeamsWebhookUrl string
 	}
 	tests := []struct {
 		name   string
@@ -29,6 +33,8 @@ func TestFetcher_Reset(t *testing.T) {
 				Url:      validUrl,
 				Interval: 15,
 				JobId:    18,
+				Emails:   []string{"ops@example.com"},
+				SlackWebhookUrl: "https://hooks.slack.com/services/T000/B000/XXX",
 			},
 		},
 	}
@@ -39,6 +45,9 @@ func TestFetcher_Reset(t *testing.T) {
 				Url:      tt.fields.Url,
 				Interval: tt.fields.Interval,
 				JobId:    tt.fields.JobId,
+				Emails:          tt.fields.Emails,
+				SlackWebhookUrl: tt.fields.SlackWebhookUrl,
+				TeamsWebhookUrl: tt.fields.TeamsWebhookUrl,
 			}
 			f.Reset()
 			if !reflect.DeepEqual(f, &Fetcher{}) {
@@ -54,6 +63,11 @@ func TestFetcher_Validate(t *testing.T) {
 		Url      string
 		Interval int
 		JobId    int
+		Emails          []string
+		SlackWebhookUrl string
+		TeamsWebhookUrl string
+		Name            string
+		Labels          map[string]string
 	}
 	tests := []struct {
 		name    string
@@ -84,6 +98,71 @@ func TestFetcher_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:    "positive_validate_emails",
+			fields:  fields{
+				Url:      validUrl,
+				Interval: 5,
+				Emails:   []string{"ops@example.com", "Jan Kowalski <jan@example.com>"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_invalid_email_error",
+			fields:  fields{
+				Url:      validUrl,
+				Interval: 5,
+				Emails:   []string{"abc"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "positive_validate_chat_webhooks",
+			fields:  fields{
+				Url:             validUrl,
+				Interval:        5,
+				SlackWebhookUrl: "https://hooks.slack.com/services/T000/B000/XXX",
+				TeamsWebhookUrl: "https://example.webhook.office.com/webhookb2/abc",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_invalid_slack_webhook_error",
+			fields:  fields{
+				Url:             validUrl,
+				Interval:        5,
+				SlackWebhookUrl: invalidUrl,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "positive_validate_name_and_labels",
+			fields:  fields{
+				Url:      validUrl,
+				Interval: 5,
+				Name:     "payments api health",
+				Labels:   map[string]string{"env": "prod"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_too_long_name_error",
+			fields:  fields{
+				Url:      validUrl,
+				Interval: 5,
+				Name:     strings.Repeat("a", 129),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_invalid_label_error",
+			fields:  fields{
+				Url:      validUrl,
+				Interval: 5,
+				Labels:   map[string]string{"env": "prod, staging"},
+			},
+			wantErr: true,
+		},
 		{
 			name:    "negative_validate_invalid_interval_error",
 			fields:  fields{
@@ -100,6 +179,11 @@ func TestFetcher_Validate(t *testing.T) {
 				Url:      tt.fields.Url,
 				Interval: tt.fields.Interval,
 				JobId:    tt.fields.JobId,
+				Emails:          tt.fields.Emails,
+				SlackWebhookUrl: tt.fields.SlackWebhookUrl,
+				TeamsWebhookUrl: tt.fields.TeamsWebhookUrl,
+				Name:            tt.fields.Name,
+				Labels:          tt.fields.Labels,
 			}
 			if err := f.Validate(); (err != nil) != tt.wantErr {
 				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
@@ -107,3 +191,99 @@ func TestFetcher_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestFetcher_Validate_Proxy(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   string
+		wantErr bool
+	}{
+		{
+			name:    "positive_validate_direct",
+			proxy:   ProxyDirect,
+			wantErr: false,
+		},
+		{
+			name:    "positive_validate_http_proxy",
+			proxy:   "http://proxy.example.com:3128",
+			wantErr: false,
+		},
+		{
+			name:    "positive_validate_socks5_proxy_with_secret",
+			proxy:   "socks5://fetcher:${secret:proxy-password}@proxy.example.com:1080",
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_scheme_error",
+			proxy:   "ftp://proxy.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_no_host_error",
+			proxy:   "proxy.example.com",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Fetcher{Url: validUrl, Interval: 5, Proxy: tt.proxy}
+			if err := f.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetcher_RedactWebhooks(t *testing.T) {
+	stored := Fetcher{
+		Url:             "https://example.com",
+		Interval:        60,
+		SlackWebhookUrl: "https://hooks.slack.com/services/T0/B0/s3cret",
+		TeamsWebhookUrl: "https://example.webhook.office.com/webhookb2/${secret:teams}",
+	}
+
+	redacted := stored
+	redacted.RedactWebhooks()
+	if redacted.SlackWebhookUrl != "https://hooks.slack.com/xxxxx" {
+		t.Errorf("Expected redacted slack url, got: %s", redacted.SlackWebhookUrl)
+	}
+	if redacted.TeamsWebhookUrl != stored.TeamsWebhookUrl {
+		t.Errorf("Expected url referencing secret to be kept, got: %s", redacted.TeamsWebhookUrl)
+	}
+	if err := stored.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if err := redacted.Validate(); err == nil {
+		t.Error("Expected redacted url to be rejected")
+	}
+
+	redacted.KeepWebhooks(&stored)
+	if redacted.SlackWebhookUrl != stored.SlackWebhookUrl {
+		t.Errorf("Expected stored slack url, got: %s", redacted.SlackWebhookUrl)
+	}
+
+	other := Fetcher{SlackWebhookUrl: "https://hooks.slack.com/xxxxx"}
+	other.KeepWebhooks(&Fetcher{SlackWebhookUrl: "https://other.example.com/hook"})
+	if other.SlackWebhookUrl != "https://hooks.slack.com/xxxxx" {
+		t.Errorf("Expected redacted url of other host to stay, got: %s", other.SlackWebhookUrl)
+	}
+}
+
+func TestFetcher_RestoreWebhooks(t *testing.T) {
+	current := Fetcher{SlackWebhookUrl: "https://hooks.slack.com/services/T0/B0/secret"}
+	restored := Fetcher{SlackWebhookUrl: "https://hooks.slack.com/xxxxx", TeamsWebhookUrl: "${secret:teams}"}
+
+	restored.RestoreWebhooks(&current)
+	if restored.SlackWebhookUrl != current.SlackWebhookUrl {
+		t.Errorf("Expected current slack url, got: %s", restored.SlackWebhookUrl)
+	}
+	if restored.TeamsWebhookUrl != "${secret:teams}" {
+		t.Errorf("Expected url referencing secret to be restored, got: %s", restored.TeamsWebhookUrl)
+	}
+
+	removed := Fetcher{SlackWebhookUrl: "https://hooks.slack.com/xxxxx"}
+	removed.RestoreWebhooks(&Fetcher{})
+	if len(removed.SlackWebhookUrl) > 0 {
+		t.Errorf("Expected removed slack url to stay removed, got: %s", removed.SlackWebhookUrl)
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+const (
+	StatusOk   = "ok"
+	StatusFail = "fail"
+)
+
+type Health struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks,omitempty"`
+}
+
+type Check struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
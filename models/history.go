@@ -1,14 +1,42 @@
 package models
 
 type History struct {
-	FetcherId int     `json:"-"`
-	Response  *string `json:"response"`
-	Duration  float64 `json:"duration"`
-	CreatedAt int64   `json:"created_at"`
+	FetcherId  int      `json:"-"`
+	Revision   int      `json:"revision,omitempty"`
+	Response   *string  `json:"response"`
+	Duration   float64  `json:"duration"`
+	CreatedAt  int64    `json:"created_at"`
+	StatusCode int      `json:"status_code"`
+	Error      *string  `json:"error,omitempty"`
+	Timings    *Timings `json:"timings,omitempty"`
+
+	// Redirects lists followed and stopped redirects in order, StatusCode is the status of the last response
+	Redirects []Redirect `json:"redirects,omitempty"`
+
+	Certificates  []Certificate `json:"certificates,omitempty"`
+	CertExpiresAt *int64        `json:"cert_expires_at,omitempty"`
+}
+
+// Timings break request duration down into phases, all in seconds.
+// DNS, connect and TLS are 0 when connection was reused, TTFB is measured from writing the request.
+type Timings struct {
+	Dns      float64 `json:"dns"`
+	Connect  float64 `json:"connect"`
+	Tls      float64 `json:"tls"`
+	Ttfb     float64 `json:"ttfb"`
+	Transfer float64 `json:"transfer"`
+	Reused   bool    `json:"reused"`
 }
 
 func (h *History) Reset() {
+	h.Revision = 0
 	h.Response = nil
 	h.Duration = 0
 	h.CreatedAt = 0
+	h.StatusCode = 0
+	h.Error = nil
+	h.Timings = nil
+	h.Redirects = nil
+	h.Certificates = nil
+	h.CertExpiresAt = nil
 }
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	SelectorEquals    = "="
+	SelectorNotEquals = "!="
+	SelectorExists    = "exists"
+	SelectorNotExists = "!exists"
+
+	maxLabels = 64
+)
+
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,62})$`)
+	labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9._/-]{0,63}$`)
+)
+
+// ValidateLabels checks that there are at most 64 labels, keys have 1 to 63 letters, digits or "._/-" starting
+// with letter or digit, and values have up to 63 such characters, so both can be used in selectors
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > maxLabels {
+		return fmt.Errorf("at most %d labels are allowed", maxLabels)
+	}
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key %q", key)
+		}
+		if !labelValuePattern.MatchString(value) {
+			return fmt.Errorf("invalid value of label %q", key)
+		}
+	}
+
+	return nil
+}
+
+// LabelRequirement is a single condition of Selector, Value is used only by SelectorEquals and SelectorNotEquals
+type LabelRequirement struct {
+	Key      string
+	Operator string
+	Value    string
+}
+
+// Selector matches labels which meet all of its requirements
+type Selector []LabelRequirement
+
+// ParseSelector parses comma separated requirements: "key=value", "key!=value", "key" for labels which
+// have the key and "!key" for ones which don't. Labels without the key don't equal any value.
+func ParseSelector(s string) (Selector, error) {
+	if len(strings.TrimSpace(s)) == 0 {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	selector := make(Selector, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+
+		var requirement LabelRequirement
+		switch {
+		case strings.Contains(part, SelectorNotEquals):
+			kv := strings.SplitN(part, SelectorNotEquals, 2)
+			requirement = LabelRequirement{Key: kv[0], Operator: SelectorNotEquals, Value: kv[1]}
+		case strings.Contains(part, SelectorEquals):
+			kv := strings.SplitN(part, SelectorEquals, 2)
+			requirement = LabelRequirement{Key: kv[0], Operator: SelectorEquals, Value: kv[1]}
+		case strings.HasPrefix(part, "!"):
+			requirement = LabelRequirement{Key: part[1:], Operator: SelectorNotExists}
+		default:
+			requirement = LabelRequirement{Key: part, Operator: SelectorExists}
+		}
+
+		requirement.Key = strings.TrimSpace(requirement.Key)
+		requirement.Value = strings.TrimSpace(requirement.Value)
+		if !labelKeyPattern.MatchString(requirement.Key) || !labelValuePattern.MatchString(requirement.Value) {
+			return nil, errors.New("invalid selector")
+		}
+		selector = append(selector, requirement)
+	}
+
+	return selector, nil
+}
+
+// Narrowing tells whether selector has at least one "key=value" or "key" requirement. Selectors made of
+// negative requirements only match every fetcher lacking a label, which is too broad for bulk operations.
+func (s Selector) Narrowing() bool {
+	for _, requirement := range s {
+		if requirement.Operator == SelectorEquals || requirement.Operator == SelectorExists {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches tells whether labels meet all requirements of selector, empty selector matches everything
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, requirement := range s {
+		value, ok := labels[requirement.Key]
+		switch requirement.Operator {
+		case SelectorEquals:
+			if !ok || value != requirement.Value {
+				return false
+			}
+		case SelectorNotEquals:
+			if ok && value == requirement.Value {
+				return false
+			}
+		case SelectorExists:
+			if !ok {
+				return false
+			}
+		case SelectorNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "positive_validate_labels",
+			labels: map[string]string{"env": "prod", "team.io/owner": "payments", "canary": ""},
+		},
+		{
+			name:    "negative_validate_invalid_key_error",
+			labels:  map[string]string{"-env": "prod"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_empty_key_error",
+			labels:  map[string]string{"": "prod"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_invalid_value_error",
+			labels:  map[string]string{"env": "prod,staging"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_too_long_value_error",
+			labels:  map[string]string{"env": strings.Repeat("a", 64)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateLabels(tt.labels); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		want     Selector
+		wantErr  bool
+	}{
+		{
+			name:     "positive_parse_empty_selector",
+			selector: " ",
+			want:     nil,
+		},
+		{
+			name:     "positive_parse_selector",
+			selector: "env=prod, tier!=gold,canary,!legacy",
+			want: Selector{
+				{Key: "env", Operator: SelectorEquals, Value: "prod"},
+				{Key: "tier", Operator: SelectorNotEquals, Value: "gold"},
+				{Key: "canary", Operator: SelectorExists},
+				{Key: "legacy", Operator: SelectorNotExists},
+			},
+		},
+		{
+			name:     "negative_parse_missing_key_error",
+			selector: "=prod",
+			wantErr:  true,
+		},
+		{
+			name:     "negative_parse_empty_requirement_error",
+			selector: "env=prod,",
+			wantErr:  true,
+		},
+		{
+			name:     "negative_parse_invalid_value_error",
+			selector: "env=prod=eu",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSelector(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSelector() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "team": "payments"}
+	tests := []struct {
+		name     string
+		selector string
+		want     bool
+	}{
+		{name: "positive_match_empty_selector", selector: "", want: true},
+		{name: "positive_match_equals", selector: "env=prod,team=payments", want: true},
+		{name: "positive_match_not_equals", selector: "env!=staging,tier!=gold", want: true},
+		{name: "positive_match_exists", selector: "team,!legacy", want: true},
+		{name: "negative_match_equals", selector: "env=staging", want: false},
+		{name: "negative_match_missing_key", selector: "tier=gold", want: false},
+		{name: "negative_match_not_exists", selector: "!env", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("ParseSelector() error = %v", err)
+			}
+			if got := selector.Matches(labels); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelector_Narrowing(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		want     bool
+	}{
+		{name: "positive_narrowing_equals", selector: "env=prod", want: true},
+		{name: "positive_narrowing_exists", selector: "!legacy,team", want: true},
+		{name: "negative_narrowing_empty", selector: "", want: false},
+		{name: "negative_narrowing_not_equals", selector: "a!=b", want: false},
+		{name: "negative_narrowing_not_exists", selector: "!nonexistent", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("ParseSelector() error = %v", err)
+			}
+			if got := selector.Narrowing(); got != tt.want {
+				t.Errorf("Narrowing() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import "fmt"
+
+const (
+	RedirectFollow   = "follow"
+	RedirectNone     = "none"
+	RedirectSameHost = "same_host"
+
+	DefaultMaxRedirects = 10
+	maxRedirects        = 50
+)
+
+// RedirectPolicy controls how fetcher follows redirects. Empty mode follows them like a plain client,
+// none returns the redirect response itself and same_host stops at first redirect to a different host.
+type RedirectPolicy struct {
+	Mode    string `json:"mode,omitempty"`
+	MaxHops int    `json:"max_hops,omitempty"`
+}
+
+// Redirect is a single hop of redirect chain: response status code and location it pointed to
+type Redirect struct {
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+func (r *RedirectPolicy) Validate() error {
+	switch r.Mode {
+	case "", RedirectFollow, RedirectNone, RedirectSameHost:
+	default:
+		return fmt.Errorf("invalid redirect mode %q", r.Mode)
+	}
+
+	if r.MaxHops < 0 || r.MaxHops > maxRedirects {
+		return fmt.Errorf("redirect max hops must be between 0 and %d", maxRedirects)
+	}
+
+	return nil
+}
+
+// Hops returns maximum number of redirects to follow, DefaultMaxRedirects if not set
+func (r *RedirectPolicy) Hops() int {
+	if r == nil || r.MaxHops == 0 {
+		return DefaultMaxRedirects
+	}
+
+	return r.MaxHops
+}
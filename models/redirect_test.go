@@ -0,0 +1,50 @@
+package models
+
+import "testing"
+
+func TestRedirectPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RedirectPolicy
+		wantErr bool
+	}{
+		{
+			name:    "positive_validate_default",
+			policy:  RedirectPolicy{},
+			wantErr: false,
+		},
+		{
+			name:    "positive_validate_same_host",
+			policy:  RedirectPolicy{Mode: RedirectSameHost, MaxHops: 3},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_mode_error",
+			policy:  RedirectPolicy{Mode: "sometimes"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_max_hops_error",
+			policy:  RedirectPolicy{Mode: RedirectFollow, MaxHops: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedirectPolicy_Hops(t *testing.T) {
+	var policy *RedirectPolicy
+	if policy.Hops() != DefaultMaxRedirects {
+		t.Errorf("Hops() = %d, want %d", policy.Hops(), DefaultMaxRedirects)
+	}
+	policy = &RedirectPolicy{MaxHops: 3}
+	if policy.Hops() != 3 {
+		t.Errorf("Hops() = %d, want 3", policy.Hops())
+	}
+}
@@ -0,0 +1,10 @@
+package models
+
+// FetcherRevision is definition of fetcher as it was stored by create, update or restore. Auth secret and
+// credentials of chat webhook urls aren't part of it, restored fetcher keeps its current ones.
+type FetcherRevision struct {
+	FetcherId  int     `json:"fetcher_id" pg:",pk"`
+	Revision   int     `json:"revision" pg:",pk"`
+	Definition Fetcher `json:"definition"`
+	CreatedAt  int64   `json:"created_at"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const maxSecretHosts = 32
+
+var (
+	secretName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+	secretHost = regexp.MustCompile(`^(\*?\.)?[A-Za-z0-9.:-]{1,253}$`)
+
+	// SecretReference matches ${secret:name} references to stored secrets
+	SecretReference = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_.-]+)\}`)
+)
+
+// Secret is a named value kept encrypted in the secret store. Value is accepted on write only,
+// Envelope is what gets stored. Fetchers may send secret only to Hosts, host names, IPs or ".domain" suffixes.
+type Secret struct {
+	TenantId  int      `json:"-" pg:",pk"`
+	Name      string   `json:"name" pg:",pk"`
+	Value     string   `json:"value,omitempty" pg:"-"`
+	Envelope  string   `json:"-"`
+	Hosts     []string `json:"hosts,omitempty" pg:",array"`
+	CreatedAt int64    `json:"created_at"`
+	UpdatedAt int64    `json:"updated_at"`
+}
+
+func (s *Secret) Validate() error {
+	if !secretName.MatchString(s.Name) {
+		return errors.New("secret name must be 1-64 letters, digits, '_', '.' or '-'")
+	}
+
+	if len(s.Value) == 0 {
+		return errors.New("secret value can't be empty")
+	}
+
+	if len(s.Hosts) > maxSecretHosts {
+		return fmt.Errorf("secret can be bound to at most %d hosts", maxSecretHosts)
+	}
+	for i, host := range s.Hosts {
+		if !secretHost.MatchString(host) {
+			return fmt.Errorf("invalid secret host %q", host)
+		}
+		s.Hosts[i] = strings.ToLower(strings.TrimPrefix(host, "*"))
+	}
+
+	return nil
+}
+
+// AllowsHost tells whether secret may be sent to host, ".domain" entries match the domain and its subdomains
+func (s *Secret) AllowsHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range s.Hosts {
+		if host == allowed || (strings.HasPrefix(allowed, ".") && (host == allowed[1:] || strings.HasSuffix(host, allowed))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SecretHost returns host of url which secrets referenced by fetcher are sent to. References in the host
+// itself are rejected, as they would decide where secrets go.
+func SecretHost(rawUrl string) (string, error) {
+	first, err := url.Parse(SecretReference.ReplaceAllString(rawUrl, "a"))
+	if err != nil {
+		return "", errors.New("invalid url")
+	}
+	second, err := url.Parse(SecretReference.ReplaceAllString(rawUrl, "b"))
+	if err != nil || first.Host != second.Host {
+		return "", errors.New("secret references aren't allowed in url host")
+	}
+
+	return first.Hostname(), nil
+}
+
+type Rotated struct {
+	Rotated int `json:"rotated"`
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSecret_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  Secret
+		wantErr bool
+	}{
+		{
+			name:    "positive_validate_secret",
+			secret:  Secret{Name: "smtp.password_1-a", Value: "s3cret"},
+			wantErr: false,
+		},
+		{
+			name:    "positive_validate_secret_with_hosts",
+			secret:  Secret{Name: "token", Value: "s3cret", Hosts: []string{"api.example.com", ".example.org", "*.example.net", "93.184.216.34"}},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_invalid_host_error",
+			secret:  Secret{Name: "token", Value: "s3cret", Hosts: []string{"https://example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_empty_name_error",
+			secret:  Secret{Value: "s3cret"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_invalid_name_error",
+			secret:  Secret{Name: "smtp password", Value: "s3cret"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_long_name_error",
+			secret:  Secret{Name: strings.Repeat("a", 65), Value: "s3cret"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_empty_value_error",
+			secret:  Secret{Name: "token"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.secret.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecret_AllowsHost(t *testing.T) {
+	secret := &Secret{Hosts: []string{"api.example.com", ".example.org"}}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "api.example.com", want: true},
+		{host: "API.example.com", want: true},
+		{host: "example.org", want: true},
+		{host: "eu.example.org", want: true},
+		{host: "example.com", want: false},
+		{host: "api.example.com.attacker.io", want: false},
+		{host: "badexample.org", want: false},
+	}
+	for _, tt := range tests {
+		if got := secret.AllowsHost(tt.host); got != tt.want {
+			t.Errorf("AllowsHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestSecretHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "positive_reference_in_query",
+			url:  "https://api.example.com:8443/?key=${secret:token}",
+			want: "api.example.com",
+		},
+		{
+			name: "positive_reference_in_user_info",
+			url:  "https://${secret:user}:${secret:password}@proxy.example.com",
+			want: "proxy.example.com",
+		},
+		{
+			name:    "negative_reference_in_host_error",
+			url:     "https://${secret:token}.attacker.io/",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SecretHost(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SecretHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SecretHost() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
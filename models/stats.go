@@ -0,0 +1,75 @@
+package models
+
+import (
+	"math"
+	"sort"
+)
+
+type Stats struct {
+	FetcherId    int            `json:"fetcher_id"`
+	Window       string         `json:"window"`
+	Runs         int            `json:"runs"`
+	Successes    int            `json:"successes"`
+	SuccessRatio float64        `json:"success_ratio"`
+	Errors       map[string]int `json:"errors"`
+	Latency      Latency        `json:"latency"`
+}
+
+type Latency struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// NewStats computes statistics from given history entries. It is meant for storages which
+// can't aggregate on their own, percentiles are interpolated the same way as postgres percentile_cont.
+func NewStats(fetcherId int, history []History) *Stats {
+	stats := &Stats{
+		FetcherId: fetcherId,
+		Runs:      len(history),
+		Errors:    make(map[string]int),
+	}
+
+	durations := make([]float64, 0, len(history))
+	for _, h := range history {
+		durations = append(durations, h.Duration)
+		if h.Error != nil {
+			stats.Errors[*h.Error]++
+			continue
+		}
+		stats.Successes++
+	}
+	sort.Float64s(durations)
+
+	stats.Latency = Latency{
+		P50: percentile(durations, 0.5),
+		P90: percentile(durations, 0.9),
+		P99: percentile(durations, 0.99),
+	}
+	if len(durations) > 0 {
+		stats.Latency.Max = durations[len(durations)-1]
+	}
+	stats.SetSuccessRatio()
+
+	return stats
+}
+
+func (s *Stats) SetSuccessRatio() {
+	s.SuccessRatio = 0
+	if s.Runs > 0 {
+		s.SuccessRatio = float64(s.Successes) / float64(s.Runs)
+	}
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []History
+		want    *Stats
+	}{
+		{
+			name:    "positive_new_stats_empty_history",
+			history: []History{},
+			want: &Stats{
+				FetcherId: 1,
+				Errors:    map[string]int{},
+			},
+		},
+		{
+			name: "positive_new_stats",
+			history: []History{
+				{Duration: 0.4},
+				{Duration: 0.1},
+				{Duration: 5, Error: pointer("timeout")},
+				{Duration: 0.2, Error: pointer("status 503")},
+				{Duration: 0.3},
+			},
+			want: &Stats{
+				FetcherId:    1,
+				Runs:         5,
+				Successes:    3,
+				SuccessRatio: 0.6,
+				Errors: map[string]int{
+					"timeout":    1,
+					"status 503": 1,
+				},
+				Latency: Latency{
+					P50: 0.3,
+					P90: 3.16,
+					P99: 4.816,
+					Max: 5,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewStats(1, tt.history); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultTenantId is the tenant created with the schema. It owns everything that existed before tenants
+// were introduced and its admins operate the whole instance.
+const DefaultTenantId = 1
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Tenant owns fetchers, webhooks, secrets and api keys. MaxFetchers limits number of its fetchers
+// and MinInterval (in seconds) their interval, zero means no limit.
+type Tenant struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	MaxFetchers int    `json:"max_fetchers"`
+	MinInterval int    `json:"min_interval"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+func (t *Tenant) Validate() error {
+	if len(t.Name) == 0 {
+		return errors.New("tenant name can't be empty")
+	}
+
+	if t.MaxFetchers < 0 {
+		return errors.New("max_fetchers can't be negative")
+	}
+
+	if t.MinInterval < 0 {
+		return errors.New("min_interval can't be negative")
+	}
+
+	return nil
+}
+
+// CheckInterval returns error wrapping ErrQuotaExceeded when interval is shorter than tenant allows
+func (t *Tenant) CheckInterval(interval int) error {
+	if t.MinInterval > 0 && interval < t.MinInterval {
+		return fmt.Errorf("%w: interval must be at least %d seconds", ErrQuotaExceeded, t.MinInterval)
+	}
+
+	return nil
+}
+
+// CheckFetchers returns error wrapping ErrQuotaExceeded when tenant having count fetchers can't add another one
+func (t *Tenant) CheckFetchers(count int) error {
+	if t.MaxFetchers > 0 && count >= t.MaxFetchers {
+		return fmt.Errorf("%w: tenant can't have more than %d fetchers", ErrQuotaExceeded, t.MaxFetchers)
+	}
+
+	return nil
+}
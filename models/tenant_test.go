@@ -0,0 +1,101 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTenant_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenant  Tenant
+		wantErr bool
+	}{
+		{
+			name:    "positive_validate_tenant",
+			tenant:  Tenant{Name: "team-a", MaxFetchers: 10, MinInterval: 60},
+			wantErr: false,
+		},
+		{
+			name:    "positive_validate_tenant_without_quotas",
+			tenant:  Tenant{Name: "team-a"},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_empty_name_error",
+			tenant:  Tenant{MaxFetchers: 10},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_negative_max_fetchers_error",
+			tenant:  Tenant{Name: "team-a", MaxFetchers: -1},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_negative_min_interval_error",
+			tenant:  Tenant{Name: "team-a", MinInterval: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tenant.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTenant_CheckQuotas(t *testing.T) {
+	tenant := Tenant{Name: "team-a", MaxFetchers: 2, MinInterval: 60}
+	tests := []struct {
+		name    string
+		tenant  Tenant
+		check   func(tenant Tenant) error
+		wantErr bool
+	}{
+		{
+			name:   "positive_interval_within_quota",
+			tenant: tenant,
+			check:  func(tenant Tenant) error { return tenant.CheckInterval(60) },
+		},
+		{
+			name:    "negative_interval_below_quota_error",
+			tenant:  tenant,
+			check:   func(tenant Tenant) error { return tenant.CheckInterval(59) },
+			wantErr: true,
+		},
+		{
+			name:   "positive_fetchers_within_quota",
+			tenant: tenant,
+			check:  func(tenant Tenant) error { return tenant.CheckFetchers(1) },
+		},
+		{
+			name:    "negative_fetchers_over_quota_error",
+			tenant:  tenant,
+			check:   func(tenant Tenant) error { return tenant.CheckFetchers(2) },
+			wantErr: true,
+		},
+		{
+			name:   "positive_no_quotas",
+			tenant: Tenant{Name: "team-a"},
+			check: func(tenant Tenant) error {
+				if err := tenant.CheckInterval(1); err != nil {
+					return err
+				}
+				return tenant.CheckFetchers(1000)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check(tt.tenant)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrQuotaExceeded) {
+				t.Errorf("Expected ErrQuotaExceeded, got: %v", err)
+			}
+		})
+	}
+}
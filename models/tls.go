@@ -0,0 +1,70 @@
+package models
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TlsConfig customizes TLS of fetcher requests. CaBundle, ClientCert and ClientKey are paths to PEM files
+// relative to tls directory configured on the host running fetcher. Custom CA bundle replaces system roots.
+type TlsConfig struct {
+	CaBundle           string `json:"ca_bundle,omitempty"`
+	ClientCert         string `json:"client_cert,omitempty"`
+	ClientKey          string `json:"client_key,omitempty"`
+	MinVersion         string `json:"min_version,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+func (t *TlsConfig) Validate() error {
+	if len(t.MinVersion) > 0 {
+		if _, ok := tlsVersions[t.MinVersion]; !ok {
+			return fmt.Errorf("invalid tls min version %q", t.MinVersion)
+		}
+	}
+
+	if (len(t.ClientCert) == 0) != (len(t.ClientKey) == 0) {
+		return errors.New("tls client cert and key must be set together")
+	}
+
+	for _, path := range t.Files() {
+		if len(path) > 0 && !isRelativePath(path) {
+			return fmt.Errorf("tls file %q must be relative to tls directory", path)
+		}
+	}
+
+	return nil
+}
+
+// Files returns paths of CA bundle, client cert and client key, unset ones are empty
+func (t *TlsConfig) Files() [3]string {
+	return [3]string{t.CaBundle, t.ClientCert, t.ClientKey}
+}
+
+func isRelativePath(path string) bool {
+	if filepath.IsAbs(path) {
+		return false
+	}
+	for _, element := range strings.Split(filepath.ToSlash(path), "/") {
+		if element == ".." {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TlsVersion returns crypto/tls constant of MinVersion, 0 (library default) if not set
+func (t *TlsConfig) TlsVersion() uint16 {
+	return tlsVersions[t.MinVersion]
+}
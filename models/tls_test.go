@@ -0,0 +1,55 @@
+package models
+
+import "testing"
+
+func TestTlsConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tls     TlsConfig
+		wantErr bool
+	}{
+		{
+			name:    "positive_validate_empty",
+			tls:     TlsConfig{},
+			wantErr: false,
+		},
+		{
+			name: "positive_validate",
+			tls: TlsConfig{
+				CaBundle:   "ca.pem",
+				ClientCert: "clients/client.pem",
+				ClientKey:  "clients/client.key",
+				MinVersion: "1.2",
+				ServerName: "internal.example.com",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "negative_validate_min_version_error",
+			tls:     TlsConfig{MinVersion: "1.4"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_absolute_path_error",
+			tls:     TlsConfig{CaBundle: "/etc/passwd"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_parent_path_error",
+			tls:     TlsConfig{ClientCert: "clients/../../client.pem", ClientKey: "client.key"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_validate_cert_without_key_error",
+			tls:     TlsConfig{ClientCert: "clients/client.pem"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tls.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+type Webhook struct {
+	Id       int      `json:"id"`
+	TenantId int      `json:"-"`
+	Url      string   `json:"url"`
+	Secret   string   `json:"secret,omitempty"`
+	Events   []string `json:"events" pg:",array"`
+}
+
+func (w *Webhook) Validate() error {
+	u, err := url.ParseRequestURI(w.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("invalid url")
+	}
+
+	if len(w.Secret) == 0 {
+		return errors.New("secret can't be empty")
+	}
+
+	if len(w.Events) == 0 {
+		return errors.New("events can't be empty")
+	}
+
+	for _, event := range w.Events {
+		if !IsEventType(event) {
+			return fmt.Errorf("unknown event type %q", event)
+		}
+	}
+
+	return nil
+}
+
+type WebhookDelivery struct {
+	Id         int     `json:"id"`
+	WebhookId  int     `json:"webhook_id"`
+	Event      string  `json:"event"`
+	Payload    string  `json:"payload"`
+	Attempt    int     `json:"attempt"`
+	StatusCode int     `json:"status_code"`
+	Error      *string `json:"error,omitempty"`
+	CreatedAt  int64   `json:"created_at"`
+}
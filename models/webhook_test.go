@@ -0,0 +1,62 @@
+package models
+
+import "testing"
+
+func TestWebhook_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook Webhook
+		wantErr bool
+	}{
+		{
+			name: "positive_validate",
+			webhook: Webhook{
+				Url:    validUrl,
+				Secret: "secret",
+				Events: []string{EventFetchFailed, EventAlertFired},
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative_validate_invalid_url_error",
+			webhook: Webhook{
+				Url:    invalidUrl,
+				Secret: "secret",
+				Events: []string{EventFetchFailed},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative_validate_empty_secret_error",
+			webhook: Webhook{
+				Url:    validUrl,
+				Events: []string{EventFetchFailed},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative_validate_empty_events_error",
+			webhook: Webhook{
+				Url:    validUrl,
+				Secret: "secret",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative_validate_unknown_event_error",
+			webhook: Webhook{
+				Url:    validUrl,
+				Secret: "secret",
+				Events: []string{"abc"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.webhook.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	slackDanger  = "danger"
+	slackWarning = "warning"
+	teamsDanger  = "d7000b"
+	teamsWarning = "ffb900"
+)
+
+// chatMessage holds what is shown for fetch failures and content changes, regardless of chat provider
+type chatMessage struct {
+	title   string
+	danger  bool
+	url     string
+	status  string
+	latency string
+	err     string
+	history string
+}
+
+func newChatMessage(event *models.Event, publicUrl string) *chatMessage {
+	msg := &chatMessage{
+		url:     event.Url,
+		status:  "-",
+		latency: fmt.Sprintf("%.3fs", event.Duration),
+	}
+	if event.StatusCode > 0 {
+		msg.status = fmt.Sprintf("%d", event.StatusCode)
+	}
+	if len(publicUrl) > 0 {
+		msg.history = fmt.Sprintf("%s/api/fetcher/%d/history", strings.TrimSuffix(publicUrl, "/"), event.FetcherId)
+	}
+
+	switch event.Type {
+	case models.EventFetchFailed:
+		msg.title = fmt.Sprintf("Fetch failed for %s", event.Url)
+		msg.danger = true
+		if event.Error != nil {
+			msg.err = *event.Error
+		}
+	case models.EventContentChanged:
+		msg.title = fmt.Sprintf("Content changed for %s", event.Url)
+	default:
+		return nil
+	}
+
+	return msg
+}
+
+// Slack posts fetch failures and content changes to Slack incoming webhook configured on the fetcher
+type Slack struct {
+	storage   storage.Storage
+	secrets   *secrets.Manager
+	client    *http.Client
+	publicUrl string
+	logger    logrus.FieldLogger
+}
+
+func NewSlack(storage storage.Storage, sm *secrets.Manager, publicUrl string, transport http.RoundTripper, l logrus.FieldLogger) *Slack {
+	return &Slack{
+		storage:   storage,
+		secrets:   sm,
+		client:    &http.Client{Transport: transport, Timeout: defaultTimeout},
+		publicUrl: publicUrl,
+		logger:    l,
+	}
+}
+
+func (s *Slack) Notify(ctx context.Context, event *models.Event) error {
+	msg := newChatMessage(event, s.publicUrl)
+	if msg == nil {
+		return nil
+	}
+
+	fetcher, err := s.storage.GetFetcher(ctx, event.TenantId, event.FetcherId)
+	if err != nil || len(fetcher.SlackWebhookUrl) == 0 {
+		return err
+	}
+
+	webhookUrl, err := resolveUrl(ctx, s.secrets, fetcher.TenantId, fetcher.SlackWebhookUrl)
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, s.client, webhookUrl, slackPayload(msg))
+}
+
+// Teams posts fetch failures and content changes to Microsoft Teams incoming webhook configured on the fetcher
+type Teams struct {
+	storage   storage.Storage
+	secrets   *secrets.Manager
+	client    *http.Client
+	publicUrl string
+	logger    logrus.FieldLogger
+}
+
+func NewTeams(storage storage.Storage, sm *secrets.Manager, publicUrl string, transport http.RoundTripper, l logrus.FieldLogger) *Teams {
+	return &Teams{
+		storage:   storage,
+		secrets:   sm,
+		client:    &http.Client{Transport: transport, Timeout: defaultTimeout},
+		publicUrl: publicUrl,
+		logger:    l,
+	}
+}
+
+func (t *Teams) Notify(ctx context.Context, event *models.Event) error {
+	msg := newChatMessage(event, t.publicUrl)
+	if msg == nil {
+		return nil
+	}
+
+	fetcher, err := t.storage.GetFetcher(ctx, event.TenantId, event.FetcherId)
+	if err != nil || len(fetcher.TeamsWebhookUrl) == 0 {
+		return err
+	}
+
+	webhookUrl, err := resolveUrl(ctx, t.secrets, fetcher.TenantId, fetcher.TeamsWebhookUrl)
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, t.client, webhookUrl, teamsPayload(msg))
+}
+
+// resolveUrl resolves secret references of chat webhook url, only secrets bound to its host can be used
+func resolveUrl(ctx context.Context, sm *secrets.Manager, tenantId int, rawUrl string) (string, error) {
+	host, err := models.SecretHost(rawUrl)
+	if err != nil {
+		return "", err
+	}
+
+	return sm.ResolveFor(ctx, tenantId, rawUrl, host)
+}
+
+func slackPayload(msg *chatMessage) map[string]interface{} {
+	color := slackWarning
+	if msg.danger {
+		color = slackDanger
+	}
+
+	fields := []map[string]interface{}{
+		{"title": "URL", "value": msg.url, "short": false},
+		{"title": "Status code", "value": msg.status, "short": true},
+		{"title": "Latency", "value": msg.latency, "short": true},
+	}
+	if len(msg.err) > 0 {
+		fields = append(fields, map[string]interface{}{"title": "Error", "value": msg.err, "short": true})
+	}
+
+	attachment := map[string]interface{}{
+		"fallback": msg.title,
+		"color":    color,
+		"title":    msg.title,
+		"fields":   fields,
+	}
+	if len(msg.history) > 0 {
+		attachment["title_link"] = msg.history
+	}
+
+	return map[string]interface{}{
+		"text":        msg.title,
+		"attachments": []interface{}{attachment},
+	}
+}
+
+func teamsPayload(msg *chatMessage) map[string]interface{} {
+	color := teamsWarning
+	if msg.danger {
+		color = teamsDanger
+	}
+
+	facts := []map[string]string{
+		{"name": "URL", "value": msg.url},
+		{"name": "Status code", "value": msg.status},
+		{"name": "Latency", "value": msg.latency},
+	}
+	if len(msg.err) > 0 {
+		facts = append(facts, map[string]string{"name": "Error", "value": msg.err})
+	}
+
+	payload := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    msg.title,
+		"themeColor": color,
+		"title":      msg.title,
+		"sections":   []interface{}{map[string]interface{}{"facts": facts}},
+	}
+	if len(msg.history) > 0 {
+		payload["potentialAction"] = []interface{}{
+			map[string]interface{}{
+				"@type":   "OpenUri",
+				"name":    "View history",
+				"targets": []map[string]string{{"os": "default", "uri": msg.history}},
+			},
+		}
+	}
+
+	return payload
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return nil
+}
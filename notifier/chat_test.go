@@ -0,0 +1,148 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
+)
+
+const publicUrl = "http://localhost:8080"
+
+func TestChat_Notify(t *testing.T) {
+	failed := "timeout"
+	tests := []struct {
+		name     string
+		teams    bool
+		event    *models.Event
+		wantPost bool
+		want     string
+	}{
+		{
+			name: "positive_slack_fetch_failed",
+			event: &models.Event{
+				Type:      models.EventFetchFailed,
+				FetcherId: 5,
+				Url:       "https://httpbin.org/range/15",
+				Duration:  5,
+				Error:     &failed,
+			},
+			wantPost: true,
+			want:     `{"attachments":[{"color":"danger","fallback":"Fetch failed for https://httpbin.org/range/15","fields":[{"short":false,"title":"URL","value":"https://httpbin.org/range/15"},{"short":true,"title":"Status code","value":"-"},{"short":true,"title":"Latency","value":"5.000s"},{"short":true,"title":"Error","value":"timeout"}],"title":"Fetch failed for https://httpbin.org/range/15","title_link":"http://localhost:8080/api/fetcher/5/history"}],"text":"Fetch failed for https://httpbin.org/range/15"}`,
+		},
+		{
+			name:  "positive_teams_content_changed",
+			teams: true,
+			event: &models.Event{
+				Type:       models.EventContentChanged,
+				FetcherId:  5,
+				Url:        "https://httpbin.org/range/15",
+				StatusCode: 200,
+				Duration:   0.25,
+			},
+			wantPost: true,
+			want:     `{"@context":"https://schema.org/extensions","@type":"MessageCard","potentialAction":[{"@type":"OpenUri","name":"View history","targets":[{"os":"default","uri":"http://localhost:8080/api/fetcher/5/history"}]}],"sections":[{"facts":[{"name":"URL","value":"https://httpbin.org/range/15"},{"name":"Status code","value":"200"},{"name":"Latency","value":"0.250s"}]}],"summary":"Content changed for https://httpbin.org/range/15","themeColor":"ffb900","title":"Content changed for https://httpbin.org/range/15"}`,
+		},
+		{
+			name: "positive_slack_not_rendered_event",
+			event: &models.Event{
+				Type:      models.EventFetcherCreated,
+				FetcherId: 5,
+			},
+			wantPost: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&got)
+			}))
+			defer server.Close()
+
+			s := &mock.Storage{
+				Fetcher: &models.Fetcher{
+					Id:              5,
+					SlackWebhookUrl: server.URL,
+					TeamsWebhookUrl: server.URL,
+				},
+			}
+			var n Notifier = NewSlack(s, nil, publicUrl, nil, logger)
+			if tt.teams {
+				n = NewTeams(s, nil, publicUrl, nil, logger)
+			}
+
+			if err := n.Notify(context.Background(), tt.event); err != nil {
+				t.Errorf("Notify() error = %v", err)
+			}
+
+			if !tt.wantPost {
+				if got != nil {
+					t.Errorf("Expected no message, got: %v", got)
+				}
+				return
+			}
+			b, _ := json.Marshal(got)
+			if string(b) != tt.want {
+				t.Errorf("Expected message: %s, got: %s", tt.want, b)
+			}
+		})
+	}
+}
+
+func TestChat_Notify_SecretReference(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	keyring, _ := secrets.ParseKeyring(masterKey)
+	tests := []struct {
+		name     string
+		hosts    []string
+		wantPath string
+		wantErr  bool
+	}{
+		{
+			name:     "positive_resolved_webhook_url",
+			hosts:    []string{serverUrl.Hostname()},
+			wantPath: "/services/T0/B0/s3cret",
+		},
+		{
+			name:    "negative_secret_not_bound_to_host_error",
+			hosts:   []string{"hooks.slack.com"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path = ""
+			s := &mock.Storage{
+				Fetcher: &models.Fetcher{
+					Id:              5,
+					TenantId:        models.DefaultTenantId,
+					SlackWebhookUrl: server.URL + "/services/T0/B0/${secret:slack}",
+				},
+			}
+			sm := secrets.NewManager(s, keyring)
+			_ = sm.Set(context.Background(), &models.Secret{TenantId: models.DefaultTenantId, Name: "slack", Value: "s3cret", Hosts: tt.hosts})
+
+			event := &models.Event{Type: models.EventFetchFailed, TenantId: models.DefaultTenantId, FetcherId: 5}
+			err := NewSlack(s, sm, publicUrl, nil, logger).Notify(context.Background(), event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if path != tt.wantPath {
+				t.Errorf("Expected request to %q, got: %q", tt.wantPath, path)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/sirupsen/logrus"
+)
+
+var defaultEmailTemplates = map[string]config.EmailTemplate{
+	models.EventAlertFired: {
+		Subject: "[fetcher] Alert fired for {{.Url}}",
+		Body:    "Alert for fetcher {{.FetcherId}} ({{.Url}}) fired at {{time .Time}}:\n{{.Alert.Message}}\n",
+	},
+	models.EventAlertResolved: {
+		Subject: "[fetcher] Alert resolved for {{.Url}}",
+		Body:    "Alert for fetcher {{.FetcherId}} ({{.Url}}) resolved at {{time .Time}}:\n{{.Alert.Message}}\n",
+	},
+	models.EventContentChanged: {
+		Subject: "[fetcher] Content changed for {{.Url}}",
+		Body:    "Response of fetcher {{.FetcherId}} ({{.Url}}) changed at {{time .Time}}, status code {{.StatusCode}}.\n",
+	},
+}
+
+var templateFuncs = template.FuncMap{
+	"time": func(unix int64) string {
+		return time.Unix(unix, 0).UTC().Format(time.RFC1123)
+	},
+}
+
+type emailTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// Email sends alert and content change events to recipients configured on the fetcher
+type Email struct {
+	storage   storage.Storage
+	conf      config.Smtp
+	templates map[string]emailTemplate
+	logger    logrus.FieldLogger
+}
+
+func NewEmail(storage storage.Storage, conf config.Smtp, l logrus.FieldLogger) (*Email, error) {
+	e := &Email{
+		storage:   storage,
+		conf:      conf,
+		templates: make(map[string]emailTemplate),
+		logger:    l,
+	}
+
+	custom := map[string]config.EmailTemplate{
+		models.EventAlertFired:     conf.Templates.AlertFired,
+		models.EventAlertResolved:  conf.Templates.AlertResolved,
+		models.EventContentChanged: conf.Templates.ContentChanged,
+	}
+	for event, def := range defaultEmailTemplates {
+		subject, body := def.Subject, def.Body
+		if len(custom[event].Subject) > 0 {
+			subject = custom[event].Subject
+		}
+		if len(custom[event].Body) > 0 {
+			body = custom[event].Body
+		}
+
+		var err error
+		t := emailTemplate{}
+		t.subject, err = template.New(event + " subject").Funcs(templateFuncs).Parse(subject)
+		if err != nil {
+			return nil, err
+		}
+		t.body, err = template.New(event + " body").Funcs(templateFuncs).Parse(body)
+		if err != nil {
+			return nil, err
+		}
+		e.templates[event] = t
+	}
+
+	return e, nil
+}
+
+func (e *Email) Notify(ctx context.Context, event *models.Event) error {
+	t, ok := e.templates[event.Type]
+	if !ok {
+		return nil
+	}
+
+	fetcher, err := e.storage.GetFetcher(ctx, event.TenantId, event.FetcherId)
+	if err != nil {
+		return err
+	}
+	if len(fetcher.Emails) == 0 {
+		return nil
+	}
+
+	subject := &bytes.Buffer{}
+	if err = t.subject.Execute(subject, event); err != nil {
+		return err
+	}
+	body := &bytes.Buffer{}
+	if err = t.body.Execute(body, event); err != nil {
+		return err
+	}
+
+	return e.send(ctx, fetcher.Emails, e.message(fetcher.Emails, subject.String(), body.Bytes()))
+}
+
+func (e *Email) message(to []string, subject string, body []byte) []byte {
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", e.conf.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", strings.TrimSpace(subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.Write(bytes.ReplaceAll(body, []byte("\n"), []byte("\r\n")))
+
+	return msg.Bytes()
+}
+
+func (e *Email) send(ctx context.Context, to []string, msg []byte) error {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(e.conf.Host, strconv.Itoa(e.conf.Port)))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, e.conf.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if e.conf.StartTLS {
+		if err = c.StartTLS(&tls.Config{ServerName: e.conf.Host}); err != nil {
+			return err
+		}
+	}
+
+	if len(e.conf.Username) > 0 {
+		if err = c.Auth(smtp.PlainAuth("", e.conf.Username, e.conf.Password, e.conf.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Mail(addressOnly(e.conf.From)); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err = c.Rcpt(addressOnly(rcpt)); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+func addressOnly(address string) string {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return address
+	}
+	return parsed.Address
+}
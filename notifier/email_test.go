@@ -0,0 +1,180 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+)
+
+// smtpServer is a minimal SMTP stand-in accepting a single message
+type smtpServer struct {
+	listener   net.Listener
+	recipients []string
+	data       string
+	done       chan struct{}
+}
+
+func newSmtpServer(t *testing.T) *smtpServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &smtpServer{listener: l, done: make(chan struct{})}
+	go s.serve()
+
+	return s
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	write := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	write("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			write("250 localhost")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.recipients = append(s.recipients, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			write("250 OK")
+		case cmd == "DATA":
+			write("354 End data with <CR><LF>.<CR><LF>")
+			data := &strings.Builder{}
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			write("250 OK")
+		case cmd == "QUIT":
+			write("221 Bye")
+			return
+		default:
+			write("250 OK")
+		}
+	}
+}
+
+func (s *smtpServer) conf() config.Smtp {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	return config.Smtp{
+		Host: host,
+		Port: p,
+		From: "fetcher@localhost",
+	}
+}
+
+func TestEmail_Notify(t *testing.T) {
+	tests := []struct {
+		name           string
+		templates      config.EmailTemplates
+		event          *models.Event
+		wantRecipients []string
+		wantData       []string
+	}{
+		{
+			name: "positive_notify_default_template",
+			event: &models.Event{
+				Type:      models.EventAlertFired,
+				FetcherId: 5,
+				Url:       "https://httpbin.org/range/15",
+				Alert:     &models.Alert{Message: "3 consecutive failures"},
+			},
+			wantRecipients: []string{"ops@example.com", "jan@example.com"},
+			wantData: []string{
+				"Subject: [fetcher] Alert fired for https://httpbin.org/range/15",
+				"3 consecutive failures",
+			},
+		},
+		{
+			name: "positive_notify_custom_template",
+			templates: config.EmailTemplates{
+				ContentChanged: config.EmailTemplate{
+					Subject: "changed {{.FetcherId}}",
+					Body:    "status {{.StatusCode}}",
+				},
+			},
+			event: &models.Event{
+				Type:       models.EventContentChanged,
+				FetcherId:  5,
+				StatusCode: 200,
+			},
+			wantRecipients: []string{"ops@example.com", "jan@example.com"},
+			wantData: []string{
+				"Subject: changed 5",
+				"status 200",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSmtpServer(t)
+			defer server.listener.Close()
+
+			conf := server.conf()
+			conf.Templates = tt.templates
+			s := &mock.Storage{
+				Fetcher: &models.Fetcher{
+					Id:     5,
+					Emails: []string{"ops@example.com", "Jan Kowalski <jan@example.com>"},
+				},
+			}
+			e, err := NewEmail(s, conf, logger)
+			if err != nil {
+				t.Fatalf("NewEmail() error = %v", err)
+			}
+
+			if err = e.Notify(context.Background(), tt.event); err != nil {
+				t.Errorf("Notify() error = %v", err)
+			}
+			<-server.done
+
+			if strings.Join(server.recipients, ",") != strings.Join(tt.wantRecipients, ",") {
+				t.Errorf("Expected recipients: %v, got: %v", tt.wantRecipients, server.recipients)
+			}
+			for _, want := range tt.wantData {
+				if !strings.Contains(server.data, want) {
+					t.Errorf("Expected message to contain %q, got: %s", want, server.data)
+				}
+			}
+		})
+	}
+}
+
+func TestNewEmail(t *testing.T) {
+	_, err := NewEmail(&mock.Storage{}, config.Smtp{
+		Templates: config.EmailTemplates{
+			AlertFired: config.EmailTemplate{Body: "{{.Url"},
+		},
+	}, logger)
+	if err == nil {
+		t.Errorf("NewEmail() expected template parse error")
+	}
+}
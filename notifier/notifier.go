@@ -0,0 +1,49 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/logging"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/sirupsen/logrus"
+)
+
+const notifyTimeout = time.Minute
+
+type Notifier interface {
+	Notify(ctx context.Context, event *models.Event) error
+}
+
+// Dispatcher passes events to all registered notifiers without blocking the caller
+type Dispatcher struct {
+	notifiers []Notifier
+	logger    logrus.FieldLogger
+}
+
+func NewDispatcher(l logrus.FieldLogger, notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{
+		notifiers: notifiers,
+		logger:    l,
+	}
+}
+
+func (d *Dispatcher) Dispatch(event models.Event) {
+	if event.Time == 0 {
+		event.Time = time.Now().Unix()
+	}
+
+	for _, n := range d.notifiers {
+		go func(n Notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+
+			if err := n.Notify(ctx, &event); err != nil {
+				d.logger.WithError(err).WithFields(logrus.Fields{
+					"event":              event.Type,
+					logging.FetcherIdKey: event.FetcherId,
+				}).Error("Notify error")
+			}
+		}(n)
+	}
+}
@@ -0,0 +1,159 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	EventHeader     = "X-Fetcher-Event"
+	SignatureHeader = "X-Fetcher-Signature"
+
+	defaultRetries = 3
+	defaultBackoff = time.Second
+	defaultTimeout = 10 * time.Second
+)
+
+// Webhook posts events as JSON to subscribed webhooks. Body is signed with HMAC-SHA256
+// using webhook secret (stored sealed) and failed deliveries are retried with exponential backoff.
+type Webhook struct {
+	storage storage.Storage
+	secrets *secrets.Manager
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	logger  logrus.FieldLogger
+}
+
+func NewWebhook(storage storage.Storage, sm *secrets.Manager, conf config.Webhook, transport http.RoundTripper, l logrus.FieldLogger) *Webhook {
+	w := &Webhook{
+		storage: storage,
+		secrets: sm,
+		client:  &http.Client{Transport: transport, Timeout: conf.Timeout},
+		retries: conf.Retries,
+		backoff: conf.Backoff,
+		logger:  l,
+	}
+	if w.retries <= 0 {
+		w.retries = defaultRetries
+	}
+	if w.backoff <= 0 {
+		w.backoff = defaultBackoff
+	}
+	if w.client.Timeout <= 0 {
+		w.client.Timeout = defaultTimeout
+	}
+
+	return w
+}
+
+func (w *Webhook) Notify(ctx context.Context, event *models.Event) error {
+	webhooks, err := w.storage.GetWebhooksForEvent(ctx, event.TenantId, event.Type)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for i := range webhooks {
+		if len(webhooks[i].Secret) > 0 {
+			webhooks[i].Secret, err = w.secrets.Open(webhooks[i].Secret)
+			if err != nil {
+				w.logger.WithError(err).WithField("webhook_id", webhooks[i].Id).Error("Webhook secret error")
+				continue
+			}
+		}
+		w.deliver(ctx, &webhooks[i], event.Type, payload)
+	}
+
+	return nil
+}
+
+func (w *Webhook) deliver(ctx context.Context, webhook *models.Webhook, event string, payload []byte) {
+	backoff := w.backoff
+	for attempt := 1; attempt <= w.retries; attempt++ {
+		delivery := &models.WebhookDelivery{
+			WebhookId: webhook.Id,
+			Event:     event,
+			Payload:   string(payload),
+			Attempt:   attempt,
+			CreatedAt: time.Now().Unix(),
+		}
+
+		statusCode, err := w.send(ctx, webhook, event, payload)
+		delivery.StatusCode = statusCode
+		if err != nil {
+			msg := err.Error()
+			delivery.Error = &msg
+		}
+
+		if err := w.storage.AddWebhookDelivery(ctx, delivery); err != nil {
+			w.logger.WithError(err).WithField("webhook_id", webhook.Id).Error("AddWebhookDelivery error")
+		}
+
+		if delivery.Error == nil {
+			return
+		}
+
+		if attempt < w.retries {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+}
+
+func (w *Webhook) send(ctx context.Context, webhook *models.Webhook, event string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(webhook.Secret, payload))
+
+	response, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return response.StatusCode, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+// Sign returns hex encoded HMAC-SHA256 of payload, receivers should compare it with X-Fetcher-Signature header
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
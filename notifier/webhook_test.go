@@ -0,0 +1,104 @@
+package notifier
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New()
+
+const (
+	secret    = "example secret"
+	masterKey = "test:MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="
+)
+
+func TestWebhook_Notify(t *testing.T) {
+	tests := []struct {
+		name           string
+		failures       int
+		retries        int
+		wantDeliveries int
+		wantErrors     int
+	}{
+		{
+			name:           "positive_notify",
+			retries:        3,
+			wantDeliveries: 1,
+		},
+		{
+			name:           "positive_notify_after_retry",
+			failures:       2,
+			retries:        3,
+			wantDeliveries: 3,
+			wantErrors:     2,
+		},
+		{
+			name:           "negative_notify_retries_exhausted",
+			failures:       5,
+			retries:        2,
+			wantDeliveries: 2,
+			wantErrors:     2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, _ := ioutil.ReadAll(r.Body)
+				if got, want := r.Header.Get(SignatureHeader), "sha256="+Sign(secret, body); got != want {
+					t.Errorf("Expected signature: %s, got: %s", want, got)
+				}
+				if got := r.Header.Get(EventHeader); got != models.EventFetchFailed {
+					t.Errorf("Expected event: %s, got: %s", models.EventFetchFailed, got)
+				}
+				if calls <= tt.failures {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}))
+			defer server.Close()
+
+			keyring, _ := secrets.ParseKeyring(masterKey)
+			sm := secrets.NewManager(&mock.Storage{}, keyring)
+			sealed, err := sm.Seal(secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := &mock.Storage{
+				Webhooks: []models.Webhook{
+					{Id: 1, Url: server.URL, Secret: sealed, Events: []string{models.EventFetchFailed}},
+				},
+			}
+			n := NewWebhook(s, sm, config.Webhook{Retries: tt.retries, Backoff: time.Millisecond}, nil, logger)
+
+			err = n.Notify(context.Background(), &models.Event{Type: models.EventFetchFailed, FetcherId: 5})
+			if err != nil {
+				t.Errorf("Notify() error = %v", err)
+			}
+
+			if len(s.Deliveries) != tt.wantDeliveries {
+				t.Errorf("Expected deliveries: %d, got: %d", tt.wantDeliveries, len(s.Deliveries))
+			}
+			errors := 0
+			for _, d := range s.Deliveries {
+				if d.Error != nil {
+					errors++
+				}
+			}
+			if errors != tt.wantErrors {
+				t.Errorf("Expected failed deliveries: %d, got: %d", tt.wantErrors, errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+)
+
+// MasterKeyEnv holds master keys, it takes precedence over key file from config
+const MasterKeyEnv = "FETCHER_MASTER_KEY"
+
+// Keyring holds master keys used to wrap data keys. New data keys are always wrapped with the primary one,
+// the others are kept only to unwrap secrets sealed before rotation.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring loads master keys from MasterKeyEnv or config key file, it returns nil keyring if neither is set
+func NewKeyring(conf config.Secrets) (*Keyring, error) {
+	if keys := os.Getenv(MasterKeyEnv); len(keys) > 0 {
+		return ParseKeyring(keys)
+	}
+	if len(conf.KeyFile) == 0 {
+		return nil, nil
+	}
+
+	b, err := ioutil.ReadFile(conf.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseKeyring(string(b))
+}
+
+// ParseKeyring parses keys in "id:base64" form separated by commas or new lines. First key is primary,
+// every key must be 32 bytes long (AES-256).
+func ParseKeyring(s string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, errors.New("master key must be in id:base64 form")
+		}
+		id := parts[0]
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("master key %q must be 32 bytes encoded in base64", id)
+		}
+		if _, ok := k.keys[id]; ok {
+			return nil, fmt.Errorf("duplicated master key %q", id)
+		}
+
+		aead, err := newAead(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+		if len(k.primary) == 0 {
+			k.primary = id
+		}
+	}
+
+	if len(k.primary) == 0 {
+		return nil, errors.New("no master key given")
+	}
+
+	return k, nil
+}
+
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,283 @@
+package secrets
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/storage"
+)
+
+const envelopeVersion = "v1"
+
+var (
+	ErrNotConfigured   = errors.New("secret store is not configured")
+	ErrInvalidEnvelope = errors.New("invalid secret envelope")
+	ErrNotBound        = errors.New("secret isn't bound to host")
+)
+
+// Manager seals values with envelope encryption: every value is encrypted with its own random data key,
+// which is wrapped with primary master key. Envelope has form "v1:<master key id>:<wrapped data key>:<ciphertext>".
+// All methods are safe to call on nil Manager, which stands for secret store without master key.
+type Manager struct {
+	storage storage.Storage
+	keyring *Keyring
+}
+
+// NewManager returns nil Manager when keyring is nil
+func NewManager(s storage.Storage, k *Keyring) *Manager {
+	if k == nil {
+		return nil
+	}
+
+	return &Manager{
+		storage: s,
+		keyring: k,
+	}
+}
+
+// In returns Manager which stores and reads secrets through s, e.g. within its transaction
+func (m *Manager) In(s storage.Storage) *Manager {
+	if m == nil {
+		return nil
+	}
+
+	return &Manager{
+		storage: s,
+		keyring: m.keyring,
+	}
+}
+
+func (m *Manager) Seal(plaintext string) (string, error) {
+	if m == nil {
+		return "", ErrNotConfigured
+	}
+
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	aead, err := newAead(dek)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(aead, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return m.wrap(dek, ciphertext)
+}
+
+// Open returns value of envelope. Values stored before secret store existed aren't envelopes,
+// they are returned as they are until SealLegacy seals them.
+func (m *Manager) Open(envelope string) (string, error) {
+	if m == nil {
+		return "", ErrNotConfigured
+	}
+	if !isEnvelope(envelope) {
+		return envelope, nil
+	}
+
+	dek, ciphertext, err := m.unwrap(envelope)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAead(dek)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// Rewrap wraps data key of envelope with primary master key, ciphertext of the value stays untouched.
+// Legacy plaintext values get sealed.
+func (m *Manager) Rewrap(envelope string) (string, error) {
+	if m == nil {
+		return "", ErrNotConfigured
+	}
+	if !isEnvelope(envelope) {
+		return m.Seal(envelope)
+	}
+
+	if keyId, _, _, err := split(envelope); err != nil || keyId == m.keyring.primary {
+		return envelope, err
+	}
+	dek, ciphertext, err := m.unwrap(envelope)
+	if err != nil {
+		return "", err
+	}
+
+	return m.wrap(dek, ciphertext)
+}
+
+// Set seals value and stores it under given name, replacing previous value
+func (m *Manager) Set(ctx context.Context, secret *models.Secret) error {
+	envelope, err := m.Seal(secret.Value)
+	if err != nil {
+		return err
+	}
+
+	secret.Envelope = envelope
+	secret.Value = ""
+	secret.UpdatedAt = time.Now().Unix()
+
+	return m.storage.SetSecret(ctx, secret)
+}
+
+// Resolve replaces every ${secret:name} reference with value of secret stored by given tenant
+func (m *Manager) Resolve(ctx context.Context, tenantId int, s string) (string, error) {
+	return m.resolve(ctx, tenantId, s, nil)
+}
+
+// ResolveFor resolves references like Resolve, but only to secrets bound to host they are sent to
+func (m *Manager) ResolveFor(ctx context.Context, tenantId int, s, host string) (string, error) {
+	return m.resolve(ctx, tenantId, s, func(secret *models.Secret) error {
+		if !secret.AllowsHost(host) {
+			return fmt.Errorf("%w %q", ErrNotBound, host)
+		}
+		return nil
+	})
+}
+
+func (m *Manager) resolve(ctx context.Context, tenantId int, s string, check func(*models.Secret) error) (string, error) {
+	if !strings.Contains(s, "${secret:") {
+		return s, nil
+	}
+	if m == nil {
+		return "", ErrNotConfigured
+	}
+
+	var err error
+	resolved := models.SecretReference.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		name := models.SecretReference.FindStringSubmatch(ref)[1]
+
+		var secret *models.Secret
+		secret, err = m.storage.GetSecret(ctx, tenantId, name)
+		if err == nil && check != nil {
+			err = check(secret)
+		}
+		if err != nil {
+			err = fmt.Errorf("secret %q: %w", name, err)
+			return ""
+		}
+
+		var value string
+		value, err = m.Open(secret.Envelope)
+		return value
+	})
+
+	return resolved, err
+}
+
+// Rotate rewraps every stored envelope with primary master key and returns number of changed ones
+func (m *Manager) Rotate(ctx context.Context) (int, error) {
+	if m == nil {
+		return 0, ErrNotConfigured
+	}
+
+	return m.storage.RewrapSecrets(ctx, m.Rewrap)
+}
+
+// SealLegacy seals every stored plaintext value left from before secret store existed, envelopes stay untouched.
+// It returns number of sealed values and has to be called once after start.
+func (m *Manager) SealLegacy(ctx context.Context) (int, error) {
+	if m == nil {
+		return 0, ErrNotConfigured
+	}
+
+	return m.storage.RewrapSecrets(ctx, func(value string) (string, error) {
+		if isEnvelope(value) {
+			return value, nil
+		}
+		return m.Seal(value)
+	})
+}
+
+func (m *Manager) wrap(dek, ciphertext []byte) (string, error) {
+	keyId := m.keyring.primary
+	wrapped, err := seal(m.keyring.keys[keyId], dek, []byte(keyId))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		envelopeVersion,
+		keyId,
+		base64.RawStdEncoding.EncodeToString(wrapped),
+		base64.RawStdEncoding.EncodeToString(ciphertext),
+	}, ":"), nil
+}
+
+func (m *Manager) unwrap(envelope string) ([]byte, []byte, error) {
+	keyId, wrapped, ciphertext, err := split(envelope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kek, ok := m.keyring.keys[keyId]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown master key %q", keyId)
+	}
+	dek, err := open(kek, wrapped, []byte(keyId))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dek, ciphertext, nil
+}
+
+func isEnvelope(value string) bool {
+	return strings.HasPrefix(value, envelopeVersion+":")
+}
+
+func split(envelope string) (string, []byte, []byte, error) {
+	parts := strings.Split(envelope, ":")
+	if len(parts) != 4 || parts[0] != envelopeVersion {
+		return "", nil, nil, ErrInvalidEnvelope
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrInvalidEnvelope
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", nil, nil, ErrInvalidEnvelope
+	}
+
+	return parts[1], wrapped, ciphertext, nil
+}
+
+// seal encrypts plaintext and prefixes it with random nonce
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/go-pg/pg/v10"
+)
+
+func (p *Postgres) GetAlertRules(ctx context.Context, tenantId, fetcherId int) ([]models.AlertRule, error) {
+	_, err := p.db.ExecOneContext(ctx, "SELECT 1 FROM fetchers WHERE id=? AND tenant_id=?", fetcherId, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]models.AlertRule, 0)
+	err = p.db.ModelContext(ctx, &rules).
+		Where("fetcher_id=?", fetcherId).
+		Order("id").
+		Select()
+
+	return rules, err
+}
+
+// AddAlertRule inserts rule only if its fetcher belongs to tenant, in the same statement, so fetcher can't change
+// hands in between. pg.ErrNoRows is returned for fetchers of other tenants.
+func (p *Postgres) AddAlertRule(ctx context.Context, tenantId int, rule *models.AlertRule) error {
+	_, err := p.db.QueryOneContext(ctx, pg.Scan(&rule.Id), `INSERT INTO alert_rules (fetcher_id, type, failures, latency, minutes)
+SELECT ?fetcher_id, ?type, ?failures, ?latency, ?minutes
+WHERE EXISTS (SELECT 1 FROM fetchers WHERE id=?fetcher_id AND tenant_id=?0)
+RETURNING id`, tenantId, rule)
+
+	return err
+}
+
+func (p *Postgres) DeleteAlertRule(ctx context.Context, tenantId, fetcherId, id int) error {
+	_, err := p.db.ExecOneContext(ctx, `DELETE FROM alert_rules
+WHERE id=? AND fetcher_id=? AND fetcher_id IN (SELECT id FROM fetchers WHERE tenant_id=?)`, id, fetcherId, tenantId)
+
+	return err
+}
+
+func (p *Postgres) GetActiveAlerts(ctx context.Context, tenantId int) ([]models.Alert, error) {
+	alerts := make([]models.Alert, 0)
+	err := p.db.ModelContext(ctx, &alerts).
+		Where("state=?", models.AlertFiring).
+		Where("fetcher_id IN (SELECT id FROM fetchers WHERE tenant_id=?)", tenantId).
+		Order("fired_at DESC").
+		Select()
+
+	return alerts, err
+}
+
+func (p *Postgres) GetFiringAlert(ctx context.Context, ruleId int) (*models.Alert, error) {
+	alert := &models.Alert{}
+	err := p.db.ModelContext(ctx, alert).
+		Where("rule_id=? AND state=?", ruleId, models.AlertFiring).
+		Select()
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+
+	return alert, err
+}
+
+func (p *Postgres) AddAlert(ctx context.Context, alert *models.Alert) error {
+	_, err := p.db.ModelContext(ctx, alert).
+		Returning("id").
+		Insert()
+
+	return err
+}
+
+func (p *Postgres) ResolveAlert(ctx context.Context, alert *models.Alert) error {
+	_, err := p.db.ModelContext(ctx, alert).
+		WherePK().
+		Set("state=?state, resolved_at=?resolved_at").
+		Update()
+
+	return err
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/go-pg/pg/v10"
+)
+
+func (p *Postgres) GetApiKeys(ctx context.Context, tenantId int) ([]models.ApiKey, error) {
+	keys := make([]models.ApiKey, 0)
+	err := p.db.ModelContext(ctx, &keys).
+		Column("id", "tenant_id", "name", "prefix", "scopes", "created_at", "revoked_at").
+		Where("tenant_id=?", tenantId).
+		Order("id").
+		Select()
+
+	return keys, err
+}
+
+// GetApiKeyByHash returns key with given hash, unless it was revoked
+func (p *Postgres) GetApiKeyByHash(ctx context.Context, hash string) (*models.ApiKey, error) {
+	key := &models.ApiKey{}
+	err := p.db.ModelContext(ctx, key).
+		Where("hash=?", hash).
+		Where("revoked_at IS NULL").
+		Select()
+
+	return key, err
+}
+
+func (p *Postgres) AddApiKey(ctx context.Context, key *models.ApiKey) error {
+	_, err := p.db.ModelContext(ctx, key).
+		Returning("id").
+		Insert()
+
+	return err
+}
+
+func (p *Postgres) RevokeApiKey(ctx context.Context, tenantId, id int, revokedAt int64) error {
+	res, err := p.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at=? WHERE id=? AND tenant_id=? AND revoked_at IS NULL",
+		revokedAt, id, tenantId)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+
+	return nil
+}
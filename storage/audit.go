@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+)
+
+// GetAuditLog returns newest entries of tenant first
+func (p *Postgres) GetAuditLog(ctx context.Context, tenantId int, filter *models.AuditFilter) ([]models.AuditEntry, error) {
+	entries := make([]models.AuditEntry, 0)
+	query := p.db.ModelContext(ctx, &entries).Where("tenant_id=?", tenantId)
+	if filter.FetcherId > 0 {
+		query.Where("fetcher_id=?", filter.FetcherId)
+	}
+	if len(filter.Actor) > 0 {
+		query.Where("actor=?", filter.Actor)
+	}
+	if filter.Since > 0 {
+		query.Where("created_at>=?", filter.Since)
+	}
+	if filter.Until > 0 {
+		query.Where("created_at<=?", filter.Until)
+	}
+	err := query.
+		Order("id DESC").
+		Limit(filter.Limit).
+		Select()
+
+	return entries, err
+}
+
+func (p *Postgres) AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	_, err := p.db.ModelContext(ctx, entry).
+		Returning("id").
+		Insert()
+
+	return err
+}
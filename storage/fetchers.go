@@ -1,58 +1,304 @@
 package storage
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
 	"github.com/BarTar213/bartlomiej-tarczynski/models"
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 )
 
-func (p *Postgres) GetFetchers() ([]models.Fetcher, error) {
+// urlHostPattern captures host of url, skipping user info
+const urlHostPattern = `^[^:]+://(?:[^@/?#]*@)?([^:/?#]+)`
+
+// fetcherSortColumns maps sort fields to expressions, null names sort as empty ones so they can be compared with cursor
+var fetcherSortColumns = map[string]string{
+	"id":       "fetcher.id",
+	"name":     "coalesce(fetcher.name, '')",
+	"url":      "fetcher.url",
+	"interval": "fetcher.interval",
+}
+
+// lastRunJoin joins the latest history entry of fetcher as last_run, its columns are null when fetcher hasn't run yet
+const lastRunJoin = `LEFT JOIN LATERAL (
+	SELECT status_code, error, created_at FROM histories
+	WHERE histories.fetcher_id = fetcher.id
+	ORDER BY histories.created_at DESC
+	LIMIT 1
+) AS last_run ON true`
+
+// GetFetchers returns page of tenant's fetchers matching filter, which has to be validated,
+// and total number of matching fetchers
+func (p *Postgres) GetFetchers(ctx context.Context, tenantId int, filter *models.FetcherFilter) ([]models.Fetcher, int, error) {
 	fetchers := make([]models.Fetcher, 0)
-	err := p.db.Model(&fetchers).Select()
+	query := p.db.ModelContext(ctx, &fetchers)
+	if err := filterFetchers(query, tenantId, filter); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := query.Clone().Count()
+	if err != nil {
+		return nil, 0, err
+	}
+	err = pageFetchers(query, filter).Select()
+
+	return fetchers, total, err
+}
+
+// filterFetchers adds conditions of filter to query of tenant's fetchers
+func filterFetchers(query *orm.Query, tenantId int, filter *models.FetcherFilter) error {
+	query.Where("fetcher.tenant_id=?", tenantId)
+	if err := whereSelector(query, filter.Selector); err != nil {
+		return err
+	}
+	if len(filter.Name) > 0 {
+		query.Where("strpos(lower(fetcher.name), lower(?)) > 0", filter.Name)
+	}
+	if len(filter.Url) > 0 {
+		query.Where("strpos(lower(fetcher.url), lower(?)) > 0", filter.Url)
+	}
+	if len(filter.Host) > 0 {
+		query.Where("lower(substring(fetcher.url from ?)) = lower(?)", urlHostPattern, filter.Host)
+	}
+	if filter.Enabled != nil {
+		query.Where("fetcher.paused=?", !*filter.Enabled)
+	}
+	if filter.MinInterval > 0 {
+		query.Where("fetcher.interval>=?", filter.MinInterval)
+	}
+	if filter.MaxInterval > 0 {
+		query.Where("fetcher.interval<=?", filter.MaxInterval)
+	}
+	if len(filter.LastStatus) > 0 {
+		query.Join(lastRunJoin)
+		switch filter.LastStatus {
+		case models.LastStatusOk:
+			query.Where("last_run.created_at IS NOT NULL AND last_run.error IS NULL AND last_run.status_code<400")
+		case models.LastStatusFailed:
+			query.Where("last_run.error IS NOT NULL OR last_run.status_code>=400")
+		case models.LastStatusNone:
+			query.Where("last_run.created_at IS NULL")
+		default:
+			query.Where("last_run.status_code=?", filter.LastStatus)
+		}
+	}
+
+	return nil
+}
+
+// pageFetchers orders query by sort of filter and limits it to page following cursor
+func pageFetchers(query *orm.Query, filter *models.FetcherFilter) *orm.Query {
+	field, desc := filter.SortField()
+	column := fetcherSortColumns[field]
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+	if cursor := filter.Cursor; cursor != nil {
+		var value interface{} = cursor.Id
+		switch field {
+		case "name":
+			value = cursor.Name
+		case "url":
+			value = cursor.Url
+		case "interval":
+			value = cursor.Interval
+		}
+		query.Where(fmt.Sprintf("(%s, fetcher.id) %s (?, ?)", column, comparison), value, cursor.Id)
+	}
+	if field != "id" {
+		query.OrderExpr(fmt.Sprintf("%s %s", column, direction))
+	}
+
+	return query.
+		OrderExpr("fetcher.id " + direction).
+		Limit(filter.Limit)
+}
+
+// GetFetchersBySelector returns tenant's fetchers whose labels match selector
+func (p *Postgres) GetFetchersBySelector(ctx context.Context, tenantId int, selector models.Selector) ([]models.Fetcher, error) {
+	fetchers := make([]models.Fetcher, 0)
+	query := p.db.ModelContext(ctx, &fetchers).Where("fetcher.tenant_id=?", tenantId)
+	if err := whereSelector(query, selector); err != nil {
+		return nil, err
+	}
+	err := query.Order("id").Select()
+
+	return fetchers, err
+}
+
+// whereSelector adds condition for every requirement of selector, equality uses GIN index on labels
+func whereSelector(query *orm.Query, selector models.Selector) error {
+	for _, requirement := range selector {
+		switch requirement.Operator {
+		case models.SelectorEquals, models.SelectorNotEquals:
+			label, err := json.Marshal(map[string]string{requirement.Key: requirement.Value})
+			if err != nil {
+				return err
+			}
+			if requirement.Operator == models.SelectorEquals {
+				query.Where("fetcher.labels @> ?::jsonb", string(label))
+			} else {
+				query.Where("NOT coalesce(fetcher.labels @> ?::jsonb, false)", string(label))
+			}
+		case models.SelectorExists:
+			query.Where("jsonb_exists(fetcher.labels, ?)", requirement.Key)
+		case models.SelectorNotExists:
+			query.Where("NOT coalesce(jsonb_exists(fetcher.labels, ?), false)", requirement.Key)
+		}
+	}
+
+	return nil
+}
+
+// GetAllFetchers returns fetchers of every tenant, to be scheduled by worker
+func (p *Postgres) GetAllFetchers(ctx context.Context) ([]models.Fetcher, error) {
+	fetchers := make([]models.Fetcher, 0)
+	err := p.db.ModelContext(ctx, &fetchers).Select()
 
 	return fetchers, err
 }
 
+func (p *Postgres) GetFetcher(ctx context.Context, tenantId, id int) (*models.Fetcher, error) {
+	fetcher := &models.Fetcher{Id: id}
+	err := p.db.ModelContext(ctx, fetcher).WherePK().Where("tenant_id=?", tenantId).Select()
+
+	return fetcher, err
+}
+
 func (p *Postgres) GetFetchersForSync() ([]models.Fetcher, error) {
 	fetchers := make([]models.Fetcher, 0)
-	err := p.db.Model(&fetchers).Where("job_id=0").Select()
+	err := p.db.ModelContext(context.Background(), &fetchers).Where("job_id=0").Select()
 
 	return fetchers, err
 }
 
-func (p *Postgres) GetFetcherJob(id int) (int, error){
-	var jobId int
-	_, err := p.db.QueryOne(pg.Scan(&jobId), "SELECT job_id FROM fetchers WHERE id=?", id)
+// AddFetcher enforces quotas of fetcher's tenant, whose row is locked until fetcher is inserted,
+// so concurrent requests can't exceed them together
+func (p *Postgres) AddFetcher(ctx context.Context, fetcher *models.Fetcher) error {
+	secret := fetcher.TakeSecret()
 
-	return jobId, err
+	return p.transaction(ctx, func(tx *pg.Tx) error {
+		tenant := &models.Tenant{Id: fetcher.TenantId}
+		err := tx.Model(tenant).WherePK().For("UPDATE").Select()
+		if err != nil {
+			return err
+		}
+		if err = tenant.CheckInterval(fetcher.Interval); err != nil {
+			return err
+		}
+
+		count, err := tx.Model((*models.Fetcher)(nil)).Where("tenant_id=?", fetcher.TenantId).Count()
+		if err != nil {
+			return err
+		}
+		if err = tenant.CheckFetchers(count); err != nil {
+			return err
+		}
+
+		fetcher.Revision = 1
+		_, err = tx.Model(fetcher).
+			Returning("id").
+			Insert()
+		if err != nil {
+			return err
+		}
+		if err = addFetcherRevision(tx, fetcher); err != nil || fetcher.Auth == nil {
+			return err
+		}
+
+		return setFetcherSecret(tx, fetcher.Id, secret)
+	})
+}
+
+// UpdateFetcher stores fetcher as its new revision. It keeps stored auth secret when none is given,
+// and removes it together with auth.
+func (p *Postgres) UpdateFetcher(ctx context.Context, fetcher *models.Fetcher) error {
+	secret := fetcher.TakeSecret()
+
+	return p.transaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.Model(fetcher).
+			WherePK().
+			Where("tenant_id=?tenant_id").
+			Set("name=?name, description=?description, labels=?labels, url=?url, interval=?interval, method=?method, headers=?headers, body=?body, job_id=?job_id, emails=?emails, slack_webhook_url=?slack_webhook_url, teams_webhook_url=?teams_webhook_url, tls=?tls, auth=?auth, redirect=?redirect, proxy=?proxy, revision=?revision").
+			Returning("id").
+			Update()
+		if err != nil {
+			return err
+		}
+		if err = addFetcherRevision(tx, fetcher); err != nil {
+			return err
+		}
+
+		if fetcher.Auth == nil {
+			_, err = tx.Exec("DELETE FROM fetcher_secrets WHERE fetcher_id=?", fetcher.Id)
+			return err
+		}
+		if len(secret) == 0 {
+			return nil
+		}
+
+		return setFetcherSecret(tx, fetcher.Id, secret)
+	})
+}
+
+// addFetcherRevision stores definition of fetcher without credentials of its chat webhook urls
+func addFetcherRevision(tx *pg.Tx, fetcher *models.Fetcher) error {
+	definition := *fetcher
+	definition.RedactWebhooks()
+
+	_, err := tx.Model(&models.FetcherRevision{
+		FetcherId:  fetcher.Id,
+		Revision:   fetcher.Revision,
+		Definition: definition,
+		CreatedAt:  time.Now().Unix(),
+	}).Insert()
+
+	return err
+}
+
+func (p *Postgres) GetFetcherSecret(ctx context.Context, fetcherId int) (string, error) {
+	var secret string
+	_, err := p.db.QueryOneContext(ctx, pg.Scan(&secret), "SELECT secret FROM fetcher_secrets WHERE fetcher_id=?", fetcherId)
+	if err == pg.ErrNoRows {
+		return "", nil
+	}
+
+	return secret, err
+}
+
+func setFetcherSecret(tx *pg.Tx, fetcherId int, secret string) error {
+	_, err := tx.Exec(`INSERT INTO fetcher_secrets (fetcher_id, secret) VALUES (?, ?)
+		ON CONFLICT (fetcher_id) DO UPDATE SET secret=EXCLUDED.secret`, fetcherId, secret)
+
+	return err
 }
 
-func (p *Postgres) AddFetcher(fetcher *models.Fetcher) error {
-	_, err := p.db.Model(fetcher).
-		Returning("id").
-		Insert()
+func (p *Postgres) UpdateFetcherJobId(ctx context.Context, fetcherId, jobId int) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE fetchers SET job_id=? WHERE id=?", jobId, fetcherId)
 
 	return err
 }
 
-func (p *Postgres) UpdateFetcher(fetcher *models.Fetcher) error {
-	_, err := p.db.Model(fetcher).
-		WherePK().
-		Set("url=?url, interval=?interval").
-		Returning("id, job_id").
-		Update()
+// SetFetcherPaused stores paused state together with job id, which is 0 for paused fetchers
+func (p *Postgres) SetFetcherPaused(ctx context.Context, tenantId, id int, paused bool, jobId int) error {
+	_, err := p.db.ExecOneContext(ctx, "UPDATE fetchers SET paused=?, job_id=? WHERE id=? AND tenant_id=?", paused, jobId, id, tenantId)
 
 	return err
 }
 
-func (p *Postgres) UpdateFetcherJobId(fetcherId, jobId int) error {
-	_, err := p.db.Exec("UPDATE fetchers SET job_id=? WHERE id=?", fetcherId, jobId)
+func (p *Postgres) UpdateFetcherCertExpiry(ctx context.Context, fetcherId int, expiresAt int64) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE fetchers SET cert_expires_at=? WHERE id=?", expiresAt, fetcherId)
 
 	return err
 }
 
-func (p *Postgres) DeleteFetcher(id int) (int, error) {
+func (p *Postgres) DeleteFetcher(ctx context.Context, tenantId, id int) (int, error) {
 	var jobId int
-	_, err := p.db.QueryOne(pg.Scan(&jobId), "DELETE FROM fetchers WHERE id=? RETURNING job_id", id)
+	_, err := p.db.QueryOneContext(ctx, pg.Scan(&jobId), "DELETE FROM fetchers WHERE id=? AND tenant_id=? RETURNING job_id", id, tenantId)
 
 	return jobId, err
 }
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// whereClause returns WHERE, ORDER BY and LIMIT clauses of select query built from given one
+func whereClause(query *orm.Query) string {
+	s := orm.NewSelectQuery(query).String()
+	if i := strings.Index(s, " WHERE "); i >= 0 {
+		return s[i+1:]
+	}
+	return ""
+}
+
+func TestFilterFetchers(t *testing.T) {
+	enabled := false
+	tests := []struct {
+		name   string
+		filter models.FetcherFilter
+		want   string
+	}{
+		{
+			name:   "positive_filter_tenant_only",
+			filter: models.FetcherFilter{},
+			want:   "WHERE (fetcher.tenant_id=1)",
+		},
+		{
+			name: "positive_filter_selector",
+			filter: models.FetcherFilter{Selector: models.Selector{
+				{Key: "env", Operator: models.SelectorEquals, Value: "prod"},
+				{Key: "tier", Operator: models.SelectorNotEquals, Value: "gold"},
+				{Key: "team", Operator: models.SelectorExists},
+				{Key: "legacy", Operator: models.SelectorNotExists},
+			}},
+			want: `WHERE (fetcher.tenant_id=1) AND (fetcher.labels @> '{"env":"prod"}'::jsonb) AND ` +
+				`(NOT coalesce(fetcher.labels @> '{"tier":"gold"}'::jsonb, false)) AND ` +
+				`(jsonb_exists(fetcher.labels, 'team')) AND (NOT coalesce(jsonb_exists(fetcher.labels, 'legacy'), false))`,
+		},
+		{
+			name:   "positive_filter_name_and_url",
+			filter: models.FetcherFilter{Name: "api", Url: "o'neil"},
+			want:   "WHERE (fetcher.tenant_id=1) AND (strpos(lower(fetcher.name), lower('api')) > 0) AND (strpos(lower(fetcher.url), lower('o''neil')) > 0)",
+		},
+		{
+			name:   "positive_filter_host",
+			filter: models.FetcherFilter{Host: "example.com"},
+			want:   "WHERE (fetcher.tenant_id=1) AND (lower(substring(fetcher.url from '^[^:]+://(?:[^@/?#]*@)?([^:/?#]+)')) = lower('example.com'))",
+		},
+		{
+			name:   "positive_filter_enabled_and_interval",
+			filter: models.FetcherFilter{Enabled: &enabled, MinInterval: 10, MaxInterval: 60},
+			want:   "WHERE (fetcher.tenant_id=1) AND (fetcher.paused=TRUE) AND (fetcher.interval>=10) AND (fetcher.interval<=60)",
+		},
+		{
+			name:   "positive_filter_last_status_ok",
+			filter: models.FetcherFilter{LastStatus: models.LastStatusOk},
+			want:   "WHERE (fetcher.tenant_id=1) AND (last_run.created_at IS NOT NULL AND last_run.error IS NULL AND last_run.status_code<400)",
+		},
+		{
+			name:   "positive_filter_last_status_failed",
+			filter: models.FetcherFilter{LastStatus: models.LastStatusFailed},
+			want:   "WHERE (fetcher.tenant_id=1) AND (last_run.error IS NOT NULL OR last_run.status_code>=400)",
+		},
+		{
+			name:   "positive_filter_last_status_none",
+			filter: models.FetcherFilter{LastStatus: models.LastStatusNone},
+			want:   "WHERE (fetcher.tenant_id=1) AND (last_run.created_at IS NULL)",
+		},
+		{
+			name:   "positive_filter_last_status_code",
+			filter: models.FetcherFilter{LastStatus: "503"},
+			want:   "WHERE (fetcher.tenant_id=1) AND (last_run.status_code='503')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := orm.NewQuery(nil, &[]models.Fetcher{})
+			if err := filterFetchers(query, 1, &tt.filter); err != nil {
+				t.Fatalf("filterFetchers() error = %v", err)
+			}
+
+			if got := whereClause(query); got != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+			joined := strings.Contains(orm.NewSelectQuery(query).String(), "AS last_run ON true")
+			if joined != (len(tt.filter.LastStatus) > 0) {
+				t.Errorf("Expected last run join only with last status filter, joined: %v", joined)
+			}
+		})
+	}
+}
+
+func TestPageFetchers(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter models.FetcherFilter
+		want   string
+	}{
+		{
+			name:   "positive_page_first",
+			filter: models.FetcherFilter{Sort: "id", Limit: 100},
+			want:   "WHERE (fetcher.tenant_id=1) ORDER BY fetcher.id ASC LIMIT 100",
+		},
+		{
+			name:   "positive_page_id_cursor",
+			filter: models.FetcherFilter{Sort: "id", Limit: 10, Cursor: &models.FetcherCursor{Sort: "id", Id: 4}},
+			want:   "WHERE (fetcher.tenant_id=1) AND ((fetcher.id, fetcher.id) > (4, 4)) ORDER BY fetcher.id ASC LIMIT 10",
+		},
+		{
+			name:   "positive_page_descending_id_cursor",
+			filter: models.FetcherFilter{Sort: "-id", Limit: 10, Cursor: &models.FetcherCursor{Sort: "-id", Id: 4}},
+			want:   "WHERE (fetcher.tenant_id=1) AND ((fetcher.id, fetcher.id) < (4, 4)) ORDER BY fetcher.id DESC LIMIT 10",
+		},
+		{
+			name:   "positive_page_name_cursor",
+			filter: models.FetcherFilter{Sort: "name", Limit: 10, Cursor: &models.FetcherCursor{Sort: "name", Id: 4, Name: "api"}},
+			want: "WHERE (fetcher.tenant_id=1) AND ((coalesce(fetcher.name, ''), fetcher.id) > ('api', 4)) " +
+				"ORDER BY coalesce(fetcher.name, '') ASC, fetcher.id ASC LIMIT 10",
+		},
+		{
+			name:   "positive_page_descending_url_cursor",
+			filter: models.FetcherFilter{Sort: "-url", Limit: 10, Cursor: &models.FetcherCursor{Sort: "-url", Id: 4, Url: "https://example.com"}},
+			want: "WHERE (fetcher.tenant_id=1) AND ((fetcher.url, fetcher.id) < ('https://example.com', 4)) " +
+				"ORDER BY fetcher.url DESC, fetcher.id DESC LIMIT 10",
+		},
+		{
+			name:   "positive_page_interval_without_cursor",
+			filter: models.FetcherFilter{Sort: "interval", Limit: 10},
+			want:   "WHERE (fetcher.tenant_id=1) ORDER BY fetcher.interval ASC, fetcher.id ASC LIMIT 10",
+		},
+		{
+			name:   "positive_page_interval_cursor",
+			filter: models.FetcherFilter{Sort: "interval", Limit: 10, Cursor: &models.FetcherCursor{Sort: "interval", Id: 4, Interval: 30}},
+			want: "WHERE (fetcher.tenant_id=1) AND ((fetcher.interval, fetcher.id) > (30, 4)) " +
+				"ORDER BY fetcher.interval ASC, fetcher.id ASC LIMIT 10",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := orm.NewQuery(nil, &[]models.Fetcher{})
+			if err := filterFetchers(query, 1, &tt.filter); err != nil {
+				t.Fatalf("filterFetchers() error = %v", err)
+			}
+
+			if got := whereClause(pageFetchers(query, &tt.filter)); got != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
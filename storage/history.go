@@ -1,23 +1,48 @@
 package storage
 
-import "github.com/BarTar213/bartlomiej-tarczynski/models"
+import (
+	"context"
 
-func (p *Postgres) GetHistory(id int) ([]models.History, error) {
-	_, err := p.db.ExecOne("SELECT 1 FROM fetchers WHERE id=?", id)
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+)
+
+func (p *Postgres) GetHistory(ctx context.Context, tenantId, id int) ([]models.History, error) {
+	_, err := p.db.ExecOneContext(ctx, "SELECT 1 FROM fetchers WHERE id=? AND tenant_id=?", id, tenantId)
 	if err != nil {
 		return nil, err
 	}
 
 	history := make([]models.History, 0)
-	err = p.db.Model(&history).
+	err = p.db.ModelContext(ctx, &history).
 		Where("fetcher_id=?", id).
 		Select()
 
 	return history, err
 }
 
-func (p *Postgres) AddHistory(history *models.History) error {
-	_, err := p.db.Model(history).Insert()
+func (p *Postgres) AddHistory(ctx context.Context, history *models.History) error {
+	_, err := p.db.ModelContext(ctx, history).Insert()
 
 	return err
 }
+
+func (p *Postgres) GetLatestHistory(ctx context.Context, fetcherId, limit int) ([]models.History, error) {
+	history := make([]models.History, 0)
+	err := p.db.ModelContext(ctx, &history).
+		Where("fetcher_id=?", fetcherId).
+		Order("created_at DESC").
+		Limit(limit).
+		Select()
+
+	return history, err
+}
+
+func (p *Postgres) GetHistorySince(ctx context.Context, fetcherId int, since int64) ([]models.History, error) {
+	history := make([]models.History, 0)
+	err := p.db.ModelContext(ctx, &history).
+		Where("fetcher_id=? AND created_at>=?", fetcherId, since).
+		Order("created_at DESC").
+		Select()
+
+	return history, err
+}
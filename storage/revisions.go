@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+)
+
+// GetFetcherRevisions returns revisions of fetcher, newest first
+func (p *Postgres) GetFetcherRevisions(ctx context.Context, tenantId, fetcherId int) ([]models.FetcherRevision, error) {
+	_, err := p.db.ExecOneContext(ctx, "SELECT 1 FROM fetchers WHERE id=? AND tenant_id=?", fetcherId, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]models.FetcherRevision, 0)
+	err = p.db.ModelContext(ctx, &revisions).
+		Where("fetcher_id=?", fetcherId).
+		Order("revision DESC").
+		Select()
+
+	return revisions, err
+}
+
+func (p *Postgres) GetFetcherRevision(ctx context.Context, tenantId, fetcherId, revision int) (*models.FetcherRevision, error) {
+	rev := &models.FetcherRevision{FetcherId: fetcherId, Revision: revision}
+	err := p.db.ModelContext(ctx, rev).
+		WherePK().
+		Where("fetcher_id IN (SELECT id FROM fetchers WHERE tenant_id=?)", tenantId).
+		Select()
+
+	return rev, err
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/go-pg/pg/v10"
+)
+
+func (p *Postgres) GetSecrets(ctx context.Context, tenantId int) ([]models.Secret, error) {
+	secrets := make([]models.Secret, 0)
+	err := p.db.ModelContext(ctx, &secrets).
+		Column("name", "hosts", "created_at", "updated_at").
+		Where("tenant_id=?", tenantId).
+		Order("name").
+		Select()
+
+	return secrets, err
+}
+
+func (p *Postgres) GetSecret(ctx context.Context, tenantId int, name string) (*models.Secret, error) {
+	secret := &models.Secret{TenantId: tenantId, Name: name}
+	err := p.db.ModelContext(ctx, secret).WherePK().Select()
+
+	return secret, err
+}
+
+// SetSecret creates secret or replaces its envelope and hosts, keeping original creation time
+func (p *Postgres) SetSecret(ctx context.Context, secret *models.Secret) error {
+	secret.CreatedAt = secret.UpdatedAt
+	_, err := p.db.ModelContext(ctx, secret).
+		OnConflict("(tenant_id, name) DO UPDATE").
+		Set("envelope=EXCLUDED.envelope, hosts=EXCLUDED.hosts, updated_at=EXCLUDED.updated_at").
+		Returning("created_at").
+		Insert()
+
+	return err
+}
+
+func (p *Postgres) DeleteSecret(ctx context.Context, tenantId int, name string) error {
+	_, err := p.db.ExecOneContext(ctx, "DELETE FROM secrets WHERE tenant_id=? AND name=?", tenantId, name)
+
+	return err
+}
+
+// sealedColumns lists every column holding secret envelopes, as table and envelope column.
+// Envelopes are unique thanks to random data keys, so rows are updated by their old envelope.
+var sealedColumns = [][2]string{
+	{"secrets", "envelope"},
+	{"fetcher_secrets", "secret"},
+	{"webhooks", "secret"},
+}
+
+// RewrapSecrets passes every stored envelope through rewrap in a single transaction,
+// so either all envelopes are moved to new master key or none
+func (p *Postgres) RewrapSecrets(ctx context.Context, rewrap func(envelope string) (string, error)) (int, error) {
+	changed := 0
+	err := p.transaction(ctx, func(tx *pg.Tx) error {
+		for _, c := range sealedColumns {
+			var rows []struct {
+				Envelope string
+			}
+			_, err := tx.Query(&rows, "SELECT ? AS envelope FROM ? WHERE ? <> '' FOR UPDATE",
+				pg.Ident(c[1]), pg.Ident(c[0]), pg.Ident(c[1]))
+			if err != nil {
+				return err
+			}
+
+			for _, row := range rows {
+				envelope, err := rewrap(row.Envelope)
+				if err != nil {
+					return err
+				}
+				if envelope == row.Envelope {
+					continue
+				}
+
+				_, err = tx.Exec("UPDATE ? SET ?=? WHERE ?=?",
+					pg.Ident(c[0]), pg.Ident(c[1]), envelope, pg.Ident(c[1]), row.Envelope)
+				if err != nil {
+					return err
+				}
+				changed++
+			}
+		}
+		return nil
+	})
+
+	return changed, err
+}
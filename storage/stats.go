@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/go-pg/pg/v10"
+)
+
+type errorCount struct {
+	Error string
+	Count int
+}
+
+func (p *Postgres) GetStats(ctx context.Context, tenantId, id int, since int64) (*models.Stats, error) {
+	_, err := p.db.ExecOneContext(ctx, "SELECT 1 FROM fetchers WHERE id=? AND tenant_id=?", id, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.Stats{
+		FetcherId: id,
+		Errors:    make(map[string]int),
+	}
+	_, err = p.db.QueryOneContext(ctx, pg.Scan(
+		&stats.Runs,
+		&stats.Successes,
+		&stats.Latency.P50,
+		&stats.Latency.P90,
+		&stats.Latency.P99,
+		&stats.Latency.Max,
+	), `SELECT count(*),
+       count(*) FILTER (WHERE error IS NULL),
+       coalesce(percentile_cont(0.5) WITHIN GROUP (ORDER BY duration), 0),
+       coalesce(percentile_cont(0.9) WITHIN GROUP (ORDER BY duration), 0),
+       coalesce(percentile_cont(0.99) WITHIN GROUP (ORDER BY duration), 0),
+       coalesce(max(duration), 0)
+FROM histories
+WHERE fetcher_id=? AND created_at>=?`, id, since)
+	if err != nil {
+		return nil, err
+	}
+
+	errors := make([]errorCount, 0)
+	_, err = p.db.QueryContext(ctx, &errors, `SELECT error, count(*) AS count
+FROM histories
+WHERE fetcher_id=? AND created_at>=? AND error IS NOT NULL
+GROUP BY error`, id, since)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range errors {
+		stats.Errors[e.Error] = e.Count
+	}
+	stats.SetSuccessRatio()
+
+	return stats, nil
+}
@@ -10,22 +10,87 @@ import (
 	"github.com/BarTar213/bartlomiej-tarczynski/config"
 	"github.com/BarTar213/bartlomiej-tarczynski/models"
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 )
 
+// Storage is scoped by tenant in every method called on behalf of API clients, so tenants can't see or change
+// each other's resources. Methods used by worker, alerts and notifiers take ids they got from scoped results.
 type Storage interface {
-	GetFetchers() ([]models.Fetcher, error)
-	GetFetcherJob(id int) (int, error)
-	AddFetcher(fetcher *models.Fetcher) error
-	UpdateFetcher(fetcher *models.Fetcher) error
-	UpdateFetcherJobId(fetcherId, jobId int) error
-	DeleteFetcher(id int) (int, error)
-
-	GetHistory(id int) ([]models.History, error)
-	AddHistory(history *models.History) error
+	Ping(ctx context.Context) error
+
+	GetTenants(ctx context.Context) ([]models.Tenant, error)
+	GetTenant(ctx context.Context, id int) (*models.Tenant, error)
+	AddTenant(ctx context.Context, tenant *models.Tenant) error
+	UpdateTenant(ctx context.Context, tenant *models.Tenant) error
+
+	GetFetchers(ctx context.Context, tenantId int, filter *models.FetcherFilter) ([]models.Fetcher, int, error)
+	GetFetchersBySelector(ctx context.Context, tenantId int, selector models.Selector) ([]models.Fetcher, error)
+	GetAllFetchers(ctx context.Context) ([]models.Fetcher, error)
+	GetFetcher(ctx context.Context, tenantId, id int) (*models.Fetcher, error)
+	AddFetcher(ctx context.Context, fetcher *models.Fetcher) error
+	UpdateFetcher(ctx context.Context, fetcher *models.Fetcher) error
+	UpdateFetcherJobId(ctx context.Context, fetcherId, jobId int) error
+	SetFetcherPaused(ctx context.Context, tenantId, id int, paused bool, jobId int) error
+	UpdateFetcherCertExpiry(ctx context.Context, fetcherId int, expiresAt int64) error
+	GetFetcherSecret(ctx context.Context, fetcherId int) (string, error)
+	DeleteFetcher(ctx context.Context, tenantId, id int) (int, error)
+
+	GetFetcherRevisions(ctx context.Context, tenantId, fetcherId int) ([]models.FetcherRevision, error)
+	GetFetcherRevision(ctx context.Context, tenantId, fetcherId, revision int) (*models.FetcherRevision, error)
+
+	GetHistory(ctx context.Context, tenantId, id int) ([]models.History, error)
+	AddHistory(ctx context.Context, history *models.History) error
+	// GetLatestHistory and GetHistorySince return runs newest first, alert rules rely on it
+	GetLatestHistory(ctx context.Context, fetcherId, limit int) ([]models.History, error)
+	GetHistorySince(ctx context.Context, fetcherId int, since int64) ([]models.History, error)
+	GetStats(ctx context.Context, tenantId, id int, since int64) (*models.Stats, error)
+
+	GetAlertRules(ctx context.Context, tenantId, fetcherId int) ([]models.AlertRule, error)
+	AddAlertRule(ctx context.Context, tenantId int, rule *models.AlertRule) error
+	DeleteAlertRule(ctx context.Context, tenantId, fetcherId, id int) error
+	GetActiveAlerts(ctx context.Context, tenantId int) ([]models.Alert, error)
+	GetFiringAlert(ctx context.Context, ruleId int) (*models.Alert, error)
+	AddAlert(ctx context.Context, alert *models.Alert) error
+	ResolveAlert(ctx context.Context, alert *models.Alert) error
+
+	GetWebhooks(ctx context.Context, tenantId int) ([]models.Webhook, error)
+	GetWebhooksForEvent(ctx context.Context, tenantId int, event string) ([]models.Webhook, error)
+	AddWebhook(ctx context.Context, webhook *models.Webhook) error
+	DeleteWebhook(ctx context.Context, tenantId, id int) error
+	GetWebhookDeliveries(ctx context.Context, tenantId, webhookId int) ([]models.WebhookDelivery, error)
+	AddWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	GetSecrets(ctx context.Context, tenantId int) ([]models.Secret, error)
+	GetSecret(ctx context.Context, tenantId int, name string) (*models.Secret, error)
+	SetSecret(ctx context.Context, secret *models.Secret) error
+	DeleteSecret(ctx context.Context, tenantId int, name string) error
+	RewrapSecrets(ctx context.Context, rewrap func(envelope string) (string, error)) (int, error)
+
+	GetApiKeys(ctx context.Context, tenantId int) ([]models.ApiKey, error)
+	GetApiKeyByHash(ctx context.Context, hash string) (*models.ApiKey, error)
+	AddApiKey(ctx context.Context, key *models.ApiKey) error
+	RevokeApiKey(ctx context.Context, tenantId, id int, revokedAt int64) error
+
+	GetAuditLog(ctx context.Context, tenantId int, filter *models.AuditFilter) ([]models.AuditEntry, error)
+	AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+
+	// RunInTransaction calls fn with storage whose calls run in one transaction, committed when fn returns nil
+	// and rolled back otherwise. Calls made within a transaction join it.
+	RunInTransaction(ctx context.Context, fn func(s Storage) error) error
+}
+
+// conn runs queries on connection pool or within transaction
+type conn interface {
+	ModelContext(c context.Context, model ...interface{}) *orm.Query
+	ExecContext(c context.Context, query interface{}, params ...interface{}) (pg.Result, error)
+	ExecOneContext(c context.Context, query interface{}, params ...interface{}) (pg.Result, error)
+	QueryContext(c context.Context, model interface{}, query interface{}, params ...interface{}) (pg.Result, error)
+	QueryOneContext(c context.Context, model interface{}, query interface{}, params ...interface{}) (pg.Result, error)
 }
 
 type Postgres struct {
-	db *pg.DB
+	pool *pg.DB
+	db   conn
 }
 
 func NewPostgres(config *config.Postgres) (Storage, error) {
@@ -46,7 +111,26 @@ func NewPostgres(config *config.Postgres) (Storage, error) {
 		return nil, fmt.Errorf("could not init tables, err: %s", err)
 	}
 
-	return &Postgres{db: db}, nil
+	return &Postgres{pool: db, db: db}, nil
+}
+
+func (p *Postgres) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
+func (p *Postgres) RunInTransaction(ctx context.Context, fn func(s Storage) error) error {
+	return p.transaction(ctx, func(tx *pg.Tx) error {
+		return fn(&Postgres{pool: p.pool, db: tx})
+	})
+}
+
+// transaction runs fn in transaction of p, or in a new one when p isn't bound to any
+func (p *Postgres) transaction(ctx context.Context, fn func(tx *pg.Tx) error) error {
+	if tx, ok := p.db.(*pg.Tx); ok {
+		return fn(tx)
+	}
+
+	return p.pool.RunInTransaction(ctx, fn)
 }
 
 func initTables(db *pg.DB) error {
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/go-pg/pg/v10"
+)
+
+func (p *Postgres) GetTenants(ctx context.Context) ([]models.Tenant, error) {
+	tenants := make([]models.Tenant, 0)
+	err := p.db.ModelContext(ctx, &tenants).
+		Order("id").
+		Select()
+
+	return tenants, err
+}
+
+func (p *Postgres) GetTenant(ctx context.Context, id int) (*models.Tenant, error) {
+	tenant := &models.Tenant{Id: id}
+	err := p.db.ModelContext(ctx, tenant).WherePK().Select()
+
+	return tenant, err
+}
+
+func (p *Postgres) AddTenant(ctx context.Context, tenant *models.Tenant) error {
+	_, err := p.db.ModelContext(ctx, tenant).
+		Returning("id").
+		Insert()
+
+	return err
+}
+
+// UpdateTenant changes name and quotas, fetchers already over new quotas are kept
+func (p *Postgres) UpdateTenant(ctx context.Context, tenant *models.Tenant) error {
+	res, err := p.db.ModelContext(ctx, tenant).
+		WherePK().
+		Set("name=?name, max_fetchers=?max_fetchers, min_interval=?min_interval").
+		Returning("created_at").
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// Traced wraps Storage and records a span for every call, child of the span in given context
+type Traced struct {
+	storage Storage
+	tracer  trace.Tracer
+}
+
+func NewTraced(s Storage, tracer trace.Tracer) Storage {
+	return &Traced{
+		storage: s,
+		tracer:  tracer,
+	}
+}
+
+func (t *Traced) start(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, "storage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgres, semconv.DBOperationKey.String(operation)),
+	)
+}
+
+func end(ctx context.Context, span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(ctx, err)
+		span.SetStatus(codes.Unknown, err.Error())
+	}
+	span.End()
+}
+
+func (t *Traced) Ping(ctx context.Context) (err error) {
+	ctx, span := t.start(ctx, "Ping")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.Ping(ctx)
+}
+
+func (t *Traced) GetTenants(ctx context.Context) (tenants []models.Tenant, err error) {
+	ctx, span := t.start(ctx, "GetTenants")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetTenants(ctx)
+}
+
+func (t *Traced) GetTenant(ctx context.Context, id int) (tenant *models.Tenant, err error) {
+	ctx, span := t.start(ctx, "GetTenant")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetTenant(ctx, id)
+}
+
+func (t *Traced) AddTenant(ctx context.Context, tenant *models.Tenant) (err error) {
+	ctx, span := t.start(ctx, "AddTenant")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.AddTenant(ctx, tenant)
+}
+
+func (t *Traced) UpdateTenant(ctx context.Context, tenant *models.Tenant) (err error) {
+	ctx, span := t.start(ctx, "UpdateTenant")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.UpdateTenant(ctx, tenant)
+}
+
+func (t *Traced) GetFetchers(ctx context.Context, tenantId int, filter *models.FetcherFilter) (fetchers []models.Fetcher, total int, err error) {
+	ctx, span := t.start(ctx, "GetFetchers")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetFetchers(ctx, tenantId, filter)
+}
+
+func (t *Traced) GetFetchersBySelector(ctx context.Context, tenantId int, selector models.Selector) (fetchers []models.Fetcher, err error) {
+	ctx, span := t.start(ctx, "GetFetchersBySelector")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetFetchersBySelector(ctx, tenantId, selector)
+}
+
+func (t *Traced) GetAllFetchers(ctx context.Context) (fetchers []models.Fetcher, err error) {
+	ctx, span := t.start(ctx, "GetAllFetchers")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetAllFetchers(ctx)
+}
+
+func (t *Traced) GetFetcher(ctx context.Context, tenantId, id int) (fetcher *models.Fetcher, err error) {
+	ctx, span := t.start(ctx, "GetFetcher")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetFetcher(ctx, tenantId, id)
+}
+
+func (t *Traced) AddFetcher(ctx context.Context, fetcher *models.Fetcher) (err error) {
+	ctx, span := t.start(ctx, "AddFetcher")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.AddFetcher(ctx, fetcher)
+}
+
+func (t *Traced) UpdateFetcher(ctx context.Context, fetcher *models.Fetcher) (err error) {
+	ctx, span := t.start(ctx, "UpdateFetcher")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.UpdateFetcher(ctx, fetcher)
+}
+
+func (t *Traced) UpdateFetcherJobId(ctx context.Context, fetcherId, jobId int) (err error) {
+	ctx, span := t.start(ctx, "UpdateFetcherJobId")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.UpdateFetcherJobId(ctx, fetcherId, jobId)
+}
+
+func (t *Traced) SetFetcherPaused(ctx context.Context, tenantId, id int, paused bool, jobId int) (err error) {
+	ctx, span := t.start(ctx, "SetFetcherPaused")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.SetFetcherPaused(ctx, tenantId, id, paused, jobId)
+}
+
+func (t *Traced) UpdateFetcherCertExpiry(ctx context.Context, fetcherId int, expiresAt int64) (err error) {
+	ctx, span := t.start(ctx, "UpdateFetcherCertExpiry")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.UpdateFetcherCertExpiry(ctx, fetcherId, expiresAt)
+}
+
+func (t *Traced) GetFetcherSecret(ctx context.Context, fetcherId int) (secret string, err error) {
+	ctx, span := t.start(ctx, "GetFetcherSecret")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetFetcherSecret(ctx, fetcherId)
+}
+
+func (t *Traced) DeleteFetcher(ctx context.Context, tenantId, id int) (jobId int, err error) {
+	ctx, span := t.start(ctx, "DeleteFetcher")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.DeleteFetcher(ctx, tenantId, id)
+}
+
+func (t *Traced) GetFetcherRevisions(ctx context.Context, tenantId, fetcherId int) (revisions []models.FetcherRevision, err error) {
+	ctx, span := t.start(ctx, "GetFetcherRevisions")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetFetcherRevisions(ctx, tenantId, fetcherId)
+}
+
+func (t *Traced) GetFetcherRevision(ctx context.Context, tenantId, fetcherId, revision int) (rev *models.FetcherRevision, err error) {
+	ctx, span := t.start(ctx, "GetFetcherRevision")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetFetcherRevision(ctx, tenantId, fetcherId, revision)
+}
+
+func (t *Traced) GetHistory(ctx context.Context, tenantId, id int) (history []models.History, err error) {
+	ctx, span := t.start(ctx, "GetHistory")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetHistory(ctx, tenantId, id)
+}
+
+func (t *Traced) AddHistory(ctx context.Context, history *models.History) (err error) {
+	ctx, span := t.start(ctx, "AddHistory")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.AddHistory(ctx, history)
+}
+
+func (t *Traced) GetLatestHistory(ctx context.Context, fetcherId, limit int) (history []models.History, err error) {
+	ctx, span := t.start(ctx, "GetLatestHistory")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetLatestHistory(ctx, fetcherId, limit)
+}
+
+func (t *Traced) GetHistorySince(ctx context.Context, fetcherId int, since int64) (history []models.History, err error) {
+	ctx, span := t.start(ctx, "GetHistorySince")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetHistorySince(ctx, fetcherId, since)
+}
+
+func (t *Traced) GetStats(ctx context.Context, tenantId, id int, since int64) (stats *models.Stats, err error) {
+	ctx, span := t.start(ctx, "GetStats")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetStats(ctx, tenantId, id, since)
+}
+
+func (t *Traced) GetAlertRules(ctx context.Context, tenantId, fetcherId int) (rules []models.AlertRule, err error) {
+	ctx, span := t.start(ctx, "GetAlertRules")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetAlertRules(ctx, tenantId, fetcherId)
+}
+
+func (t *Traced) AddAlertRule(ctx context.Context, tenantId int, rule *models.AlertRule) (err error) {
+	ctx, span := t.start(ctx, "AddAlertRule")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.AddAlertRule(ctx, tenantId, rule)
+}
+
+func (t *Traced) DeleteAlertRule(ctx context.Context, tenantId, fetcherId, id int) (err error) {
+	ctx, span := t.start(ctx, "DeleteAlertRule")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.DeleteAlertRule(ctx, tenantId, fetcherId, id)
+}
+
+func (t *Traced) GetActiveAlerts(ctx context.Context, tenantId int) (alerts []models.Alert, err error) {
+	ctx, span := t.start(ctx, "GetActiveAlerts")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetActiveAlerts(ctx, tenantId)
+}
+
+func (t *Traced) GetFiringAlert(ctx context.Context, ruleId int) (alert *models.Alert, err error) {
+	ctx, span := t.start(ctx, "GetFiringAlert")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetFiringAlert(ctx, ruleId)
+}
+
+func (t *Traced) AddAlert(ctx context.Context, alert *models.Alert) (err error) {
+	ctx, span := t.start(ctx, "AddAlert")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.AddAlert(ctx, alert)
+}
+
+func (t *Traced) ResolveAlert(ctx context.Context, alert *models.Alert) (err error) {
+	ctx, span := t.start(ctx, "ResolveAlert")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.ResolveAlert(ctx, alert)
+}
+
+func (t *Traced) GetWebhooks(ctx context.Context, tenantId int) (webhooks []models.Webhook, err error) {
+	ctx, span := t.start(ctx, "GetWebhooks")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetWebhooks(ctx, tenantId)
+}
+
+func (t *Traced) GetWebhooksForEvent(ctx context.Context, tenantId int, event string) (webhooks []models.Webhook, err error) {
+	ctx, span := t.start(ctx, "GetWebhooksForEvent")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetWebhooksForEvent(ctx, tenantId, event)
+}
+
+func (t *Traced) AddWebhook(ctx context.Context, webhook *models.Webhook) (err error) {
+	ctx, span := t.start(ctx, "AddWebhook")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.AddWebhook(ctx, webhook)
+}
+
+func (t *Traced) DeleteWebhook(ctx context.Context, tenantId, id int) (err error) {
+	ctx, span := t.start(ctx, "DeleteWebhook")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.DeleteWebhook(ctx, tenantId, id)
+}
+
+func (t *Traced) GetWebhookDeliveries(ctx context.Context, tenantId, webhookId int) (deliveries []models.WebhookDelivery, err error) {
+	ctx, span := t.start(ctx, "GetWebhookDeliveries")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetWebhookDeliveries(ctx, tenantId, webhookId)
+}
+
+func (t *Traced) AddWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) (err error) {
+	ctx, span := t.start(ctx, "AddWebhookDelivery")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.AddWebhookDelivery(ctx, delivery)
+}
+
+func (t *Traced) GetSecrets(ctx context.Context, tenantId int) (secrets []models.Secret, err error) {
+	ctx, span := t.start(ctx, "GetSecrets")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetSecrets(ctx, tenantId)
+}
+
+func (t *Traced) GetSecret(ctx context.Context, tenantId int, name string) (secret *models.Secret, err error) {
+	ctx, span := t.start(ctx, "GetSecret")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetSecret(ctx, tenantId, name)
+}
+
+func (t *Traced) SetSecret(ctx context.Context, secret *models.Secret) (err error) {
+	ctx, span := t.start(ctx, "SetSecret")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.SetSecret(ctx, secret)
+}
+
+func (t *Traced) DeleteSecret(ctx context.Context, tenantId int, name string) (err error) {
+	ctx, span := t.start(ctx, "DeleteSecret")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.DeleteSecret(ctx, tenantId, name)
+}
+
+func (t *Traced) RewrapSecrets(ctx context.Context, rewrap func(envelope string) (string, error)) (changed int, err error) {
+	ctx, span := t.start(ctx, "RewrapSecrets")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.RewrapSecrets(ctx, rewrap)
+}
+
+func (t *Traced) GetApiKeys(ctx context.Context, tenantId int) (keys []models.ApiKey, err error) {
+	ctx, span := t.start(ctx, "GetApiKeys")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetApiKeys(ctx, tenantId)
+}
+
+func (t *Traced) GetApiKeyByHash(ctx context.Context, hash string) (key *models.ApiKey, err error) {
+	ctx, span := t.start(ctx, "GetApiKeyByHash")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetApiKeyByHash(ctx, hash)
+}
+
+func (t *Traced) AddApiKey(ctx context.Context, key *models.ApiKey) (err error) {
+	ctx, span := t.start(ctx, "AddApiKey")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.AddApiKey(ctx, key)
+}
+
+func (t *Traced) RevokeApiKey(ctx context.Context, tenantId, id int, revokedAt int64) (err error) {
+	ctx, span := t.start(ctx, "RevokeApiKey")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.RevokeApiKey(ctx, tenantId, id, revokedAt)
+}
+
+func (t *Traced) GetAuditLog(ctx context.Context, tenantId int, filter *models.AuditFilter) (entries []models.AuditEntry, err error) {
+	ctx, span := t.start(ctx, "GetAuditLog")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.GetAuditLog(ctx, tenantId, filter)
+}
+
+func (t *Traced) AddAuditEntry(ctx context.Context, entry *models.AuditEntry) (err error) {
+	ctx, span := t.start(ctx, "AddAuditEntry")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.AddAuditEntry(ctx, entry)
+}
+
+// RunInTransaction traces calls made within transaction as children of its span
+func (t *Traced) RunInTransaction(ctx context.Context, fn func(s Storage) error) (err error) {
+	ctx, span := t.start(ctx, "RunInTransaction")
+	defer func() { end(ctx, span, err) }()
+	return t.storage.RunInTransaction(ctx, func(s Storage) error {
+		return fn(&Traced{storage: s, tracer: t.tracer})
+	})
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+)
+
+func (p *Postgres) GetWebhooks(ctx context.Context, tenantId int) ([]models.Webhook, error) {
+	webhooks := make([]models.Webhook, 0)
+	err := p.db.ModelContext(ctx, &webhooks).
+		Column("id", "url", "events").
+		Where("tenant_id=?", tenantId).
+		Order("id").
+		Select()
+
+	return webhooks, err
+}
+
+func (p *Postgres) GetWebhooksForEvent(ctx context.Context, tenantId int, event string) ([]models.Webhook, error) {
+	webhooks := make([]models.Webhook, 0)
+	err := p.db.ModelContext(ctx, &webhooks).
+		Where("tenant_id=? AND ?=ANY(events)", tenantId, event).
+		Select()
+
+	return webhooks, err
+}
+
+func (p *Postgres) AddWebhook(ctx context.Context, webhook *models.Webhook) error {
+	_, err := p.db.ModelContext(ctx, webhook).
+		Returning("id").
+		Insert()
+
+	return err
+}
+
+func (p *Postgres) DeleteWebhook(ctx context.Context, tenantId, id int) error {
+	_, err := p.db.ExecOneContext(ctx, "DELETE FROM webhooks WHERE id=? AND tenant_id=?", id, tenantId)
+
+	return err
+}
+
+func (p *Postgres) GetWebhookDeliveries(ctx context.Context, tenantId, webhookId int) ([]models.WebhookDelivery, error) {
+	_, err := p.db.ExecOneContext(ctx, "SELECT 1 FROM webhooks WHERE id=? AND tenant_id=?", webhookId, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	err = p.db.ModelContext(ctx, &deliveries).
+		Where("webhook_id=?", webhookId).
+		Order("id DESC").
+		Limit(100).
+		Select()
+
+	return deliveries, err
+}
+
+func (p *Postgres) AddWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := p.db.ModelContext(ctx, delivery).
+		Returning("id").
+		Insert()
+
+	return err
+}
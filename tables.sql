@@ -11,6 +11,50 @@ create table if not exists fetchers
 alter table fetchers
     owner to postgres;
 
+alter table fetchers
+    add column if not exists emails text[];
+
+alter table fetchers
+    add column if not exists slack_webhook_url text;
+
+alter table fetchers
+    add column if not exists teams_webhook_url text;
+
+alter table fetchers
+    add column if not exists tls jsonb;
+
+alter table fetchers
+    add column if not exists method text;
+
+alter table fetchers
+    add column if not exists headers jsonb;
+
+alter table fetchers
+    add column if not exists body text;
+
+alter table fetchers
+    add column if not exists auth jsonb;
+
+alter table fetchers
+    add column if not exists redirect jsonb;
+
+alter table fetchers
+    add column if not exists proxy text;
+
+create table if not exists fetcher_secrets
+(
+    fetcher_id integer not null
+        constraint fetcher_secrets_pk
+            primary key
+        constraint fetcher_secrets_fetchers_id_fk
+            references fetchers
+            on delete cascade,
+    secret     text    not null
+);
+
+alter table fetcher_secrets
+    owner to postgres;
+
 create unique index if not exists fetchers_id_uindex
     on fetchers (id);
 
@@ -26,4 +70,290 @@ create table if not exists histories
 );
 
 alter table histories
-    owner to postgres;
\ No newline at end of file
+    owner to postgres;
+
+alter table histories
+    add column if not exists status_code integer;
+
+alter table histories
+    add column if not exists error text;
+
+alter table histories
+    add column if not exists timings jsonb;
+
+alter table histories
+    add column if not exists redirects jsonb;
+
+alter table histories
+    add column if not exists certificates jsonb;
+
+alter table histories
+    add column if not exists cert_expires_at bigint;
+
+alter table fetchers
+    add column if not exists cert_expires_at bigint;
+
+create index if not exists histories_fetcher_id_created_at_index
+    on histories (fetcher_id, created_at);
+
+create table if not exists alert_rules
+(
+    id         serial not null
+        constraint alert_rules_pk
+            primary key,
+    fetcher_id integer not null
+        constraint alert_rules_fetchers_id_fk
+            references fetchers
+            on delete cascade,
+    type       text    not null,
+    failures   integer,
+    latency    double precision,
+    minutes    integer
+);
+
+alter table alert_rules
+    owner to postgres;
+
+create table if not exists alerts
+(
+    id          serial not null
+        constraint alerts_pk
+            primary key,
+    rule_id     integer not null
+        constraint alerts_alert_rules_id_fk
+            references alert_rules
+            on delete cascade,
+    fetcher_id  integer not null
+        constraint alerts_fetchers_id_fk
+            references fetchers
+            on delete cascade,
+    state       text    not null,
+    message     text,
+    fired_at    double precision,
+    resolved_at double precision
+);
+
+alter table alerts
+    owner to postgres;
+
+create unique index if not exists alerts_rule_id_firing_uindex
+    on alerts (rule_id)
+    where state = 'firing';
+
+create table if not exists webhooks
+(
+    id     serial not null
+        constraint webhooks_pk
+            primary key,
+    url    text   not null,
+    secret text   not null,
+    events text[] not null
+);
+
+alter table webhooks
+    owner to postgres;
+
+create table if not exists webhook_deliveries
+(
+    id          serial  not null
+        constraint webhook_deliveries_pk
+            primary key,
+    webhook_id  integer not null
+        constraint webhook_deliveries_webhooks_id_fk
+            references webhooks
+            on delete cascade,
+    event       text    not null,
+    payload     text,
+    attempt     integer not null,
+    status_code integer,
+    error       text,
+    created_at  double precision
+);
+
+alter table webhook_deliveries
+    owner to postgres;
+
+create index if not exists webhook_deliveries_webhook_id_index
+    on webhook_deliveries (webhook_id);
+
+create table if not exists secrets
+(
+    name       text   not null
+        constraint secrets_pk
+            primary key,
+    envelope   text   not null,
+    created_at bigint not null,
+    updated_at bigint not null
+);
+
+alter table secrets
+    owner to postgres;
+
+create table if not exists api_keys
+(
+    id         serial not null
+        constraint api_keys_pk
+            primary key,
+    name       text   not null,
+    prefix     text   not null,
+    hash       text   not null,
+    scopes     text[] not null,
+    created_at bigint not null,
+    revoked_at bigint
+);
+
+create unique index if not exists api_keys_hash_uindex
+    on api_keys (hash);
+
+alter table api_keys
+    owner to postgres;
+
+create table if not exists tenants
+(
+    id           serial  not null
+        constraint tenants_pk
+            primary key,
+    name         text    not null,
+    max_fetchers integer not null default 0,
+    min_interval integer not null default 0,
+    created_at   bigint  not null default 0
+);
+
+alter table tenants
+    owner to postgres;
+
+insert into tenants (id, name)
+values (1, 'default')
+on conflict do nothing;
+
+select setval('tenants_id_seq', greatest((select max(id) from tenants), 1));
+
+alter table fetchers
+    add column if not exists tenant_id integer not null default 1
+        constraint fetchers_tenants_id_fk
+            references tenants
+            on delete cascade;
+
+create index if not exists fetchers_tenant_id_index
+    on fetchers (tenant_id);
+
+alter table webhooks
+    add column if not exists tenant_id integer not null default 1
+        constraint webhooks_tenants_id_fk
+            references tenants
+            on delete cascade;
+
+alter table api_keys
+    add column if not exists tenant_id integer not null default 1
+        constraint api_keys_tenants_id_fk
+            references tenants
+            on delete cascade;
+
+alter table secrets
+    add column if not exists tenant_id integer not null default 1
+        constraint secrets_tenants_id_fk
+            references tenants
+            on delete cascade;
+
+alter table secrets
+    drop constraint if exists secrets_pk;
+
+create unique index if not exists secrets_tenant_id_name_uindex
+    on secrets (tenant_id, name);
+
+alter table fetchers
+    add column if not exists paused boolean not null default false;
+
+create table if not exists audit_log
+(
+    id          serial  not null
+        constraint audit_log_pk
+            primary key,
+    tenant_id   integer not null,
+    actor       text    not null,
+    action      text    not null,
+    resource    text    not null,
+    resource_id text,
+    fetcher_id  integer,
+    before      jsonb,
+    after       jsonb,
+    created_at  bigint  not null
+);
+
+alter table audit_log
+    owner to postgres;
+
+create index if not exists audit_log_tenant_id_created_at_index
+    on audit_log (tenant_id, created_at);
+
+create index if not exists audit_log_fetcher_id_index
+    on audit_log (fetcher_id);
+
+create or replace rule audit_log_no_update as
+    on update to audit_log do instead nothing;
+
+create or replace rule audit_log_no_delete as
+    on delete to audit_log do instead nothing;
+
+alter table fetchers
+    add column if not exists revision integer not null default 1;
+
+alter table histories
+    add column if not exists revision integer;
+
+create table if not exists fetcher_revisions
+(
+    fetcher_id integer not null
+        constraint fetcher_revisions_fetchers_id_fk
+            references fetchers
+            on delete cascade,
+    revision   integer not null,
+    definition jsonb   not null,
+    created_at bigint  not null,
+    constraint fetcher_revisions_pk
+        primary key (fetcher_id, revision)
+);
+
+alter table fetcher_revisions
+    owner to postgres;
+
+insert into fetcher_revisions (fetcher_id, revision, definition, created_at)
+select id, revision, to_jsonb(fetchers) - 'job_id' - 'slack_webhook_url' - 'teams_webhook_url', 0
+from fetchers
+on conflict do nothing;
+
+update fetcher_revisions
+set definition = definition - 'slack_webhook_url' - 'teams_webhook_url'
+where created_at = 0;
+
+create index if not exists fetchers_tenant_id_url_index
+    on fetchers (tenant_id, url, id);
+
+create index if not exists fetchers_tenant_id_interval_index
+    on fetchers (tenant_id, interval, id);
+
+alter table fetchers
+    add column if not exists name text;
+
+alter table fetchers
+    add column if not exists description text;
+
+alter table fetchers
+    add column if not exists labels jsonb;
+
+create unique index if not exists fetchers_tenant_id_name_uindex
+    on fetchers (tenant_id, name);
+
+create index if not exists fetchers_labels_index
+    on fetchers using gin (labels);
+
+drop index if exists fetchers_tags_index;
+
+alter table fetchers
+    drop column if exists tags;
+
+alter table secrets
+    add column if not exists hosts text[];
+
+alter table audit_log
+    add column if not exists api_key_id integer;
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"fmt"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+const (
+	ExporterNone = ""
+	ExporterOtlp = "otlp"
+
+	// TracerName is instrumentation name used by every tracer of the app
+	TracerName = "github.com/BarTar213/bartlomiej-tarczynski"
+
+	defaultServiceName = "fetcher"
+)
+
+// NewProvider builds trace provider for configured exporter and registers it globally.
+// Returned stop function flushes pending spans and should be called on shutdown.
+func NewProvider(conf config.Tracing) (trace.Provider, func(), error) {
+	switch conf.Exporter {
+	case ExporterNone:
+		return trace.NoopProvider{}, func() {}, nil
+	case ExporterOtlp:
+	default:
+		return nil, nil, fmt.Errorf("unknown tracing exporter %q", conf.Exporter)
+	}
+
+	options := []otlp.ExporterOption{otlp.WithAddress(conf.Endpoint)}
+	if conf.Insecure {
+		options = append(options, otlp.WithInsecure())
+	}
+	exporter, err := otlp.NewExporter(options...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serviceName := conf.ServiceName
+	if len(serviceName) == 0 {
+		serviceName = defaultServiceName
+	}
+	processor, err := sdktrace.NewBatchSpanProcessor(exporter)
+	if err != nil {
+		_ = exporter.Stop()
+		return nil, nil, err
+	}
+	provider, err := sdktrace.NewProvider(sdktrace.WithResource(resource.New(semconv.ServiceNameKey.String(serviceName))))
+	if err != nil {
+		_ = exporter.Stop()
+		return nil, nil, err
+	}
+	provider.RegisterSpanProcessor(processor)
+	global.SetTraceProvider(provider)
+
+	stop := func() {
+		processor.Shutdown()
+		_ = exporter.Stop()
+	}
+
+	return provider, stop, nil
+}
@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// tokenSource is OAuth2 token source of fetcher, kept with the settings it was built from
+type tokenSource struct {
+	key    string
+	source oauth2.TokenSource
+}
+
+// authorize adds fetcher credentials to request, sealed secret is loaded from storage on every run
+func (w *Worker) authorize(client *http.Client, fetcher models.Fetcher, req *http.Request) error {
+	if fetcher.Auth == nil {
+		w.tokens.Delete(fetcher.Id)
+		return nil
+	}
+
+	envelope, err := w.storage.GetFetcherSecret(req.Context(), fetcher.Id)
+	if err != nil {
+		return err
+	}
+	var secret string
+	if len(envelope) > 0 {
+		if secret, err = w.secrets.Open(envelope); err != nil {
+			return err
+		}
+	}
+
+	auth := fetcher.Auth
+	switch auth.Type {
+	case models.AuthBasic:
+		req.SetBasicAuth(auth.Username, secret)
+	case models.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+secret)
+	case models.AuthApiKey:
+		req.Header.Set(auth.Header, secret)
+	case models.AuthOAuth2:
+		token, err := w.tokenSource(client, fetcher.Id, auth, secret).Token()
+		if err != nil {
+			return err
+		}
+		token.SetAuthHeader(req)
+	}
+
+	return nil
+}
+
+// tokenSource returns cached client credentials token source, which reuses token until it expires
+func (w *Worker) tokenSource(client *http.Client, fetcherId int, auth *models.Auth, secret string) oauth2.TokenSource {
+	key := strings.Join([]string{auth.TokenUrl, auth.ClientId, strings.Join(auth.Scopes, " "), secret}, "\n")
+	if cached, ok := w.tokens.Load(fetcherId); ok && cached.(*tokenSource).key == key {
+		return cached.(*tokenSource).source
+	}
+
+	conf := &clientcredentials.Config{
+		ClientID:     auth.ClientId,
+		ClientSecret: secret,
+		TokenURL:     auth.TokenUrl,
+		Scopes:       auth.Scopes,
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	source := conf.TokenSource(ctx)
+	w.tokens.Store(fetcherId, &tokenSource{key: key, source: source})
+
+	return source
+}
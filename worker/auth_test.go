@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
+)
+
+var testKeyring, _ = secrets.ParseKeyring("test:MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE=")
+
+// sealed returns storage holding fetcher secret the way it's stored by the api
+func sealed(t *testing.T, secret string) *mock.Storage {
+	envelope, err := secrets.NewManager(nil, testKeyring).Seal(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &mock.Storage{Secret: envelope}
+}
+
+func TestWorker_Authorize(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "fetcher" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"issued","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	tests := []struct {
+		name       string
+		auth       *models.Auth
+		storage    *mock.Storage
+		wantHeader string
+		wantValue  string
+		wantErr    bool
+	}{
+		{
+			name:       "positive_no_auth",
+			storage:    &mock.Storage{},
+			wantHeader: "Authorization",
+			wantValue:  "",
+		},
+		{
+			name:       "positive_basic",
+			auth:       &models.Auth{Type: models.AuthBasic, Username: "user"},
+			storage:    sealed(t, "pass"),
+			wantHeader: "Authorization",
+			wantValue:  "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:       "positive_bearer",
+			auth:       &models.Auth{Type: models.AuthBearer},
+			storage:    sealed(t, "token"),
+			wantHeader: "Authorization",
+			wantValue:  "Bearer token",
+		},
+		{
+			name:       "positive_api_key",
+			auth:       &models.Auth{Type: models.AuthApiKey, Header: "X-Api-Key"},
+			storage:    sealed(t, "key"),
+			wantHeader: "X-Api-Key",
+			wantValue:  "key",
+		},
+		{
+			name:       "positive_oauth2",
+			auth:       &models.Auth{Type: models.AuthOAuth2, TokenUrl: tokenServer.URL, ClientId: "fetcher"},
+			storage:    sealed(t, "s3cret"),
+			wantHeader: "Authorization",
+			wantValue:  "Bearer issued",
+		},
+		{
+			name:    "negative_oauth2_token_error",
+			auth:    &models.Auth{Type: models.AuthOAuth2, TokenUrl: tokenServer.URL, ClientId: "fetcher"},
+			storage: sealed(t, "wrong"),
+			wantErr: true,
+		},
+		{
+			name:    "negative_get_secret_error",
+			auth:    &models.Auth{Type: models.AuthBearer},
+			storage: &mock.Storage{GetFetcherSecretErr: true},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Worker{storage: tt.storage, secrets: secrets.NewManager(tt.storage, testKeyring)}
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+
+			err := w.authorize(http.DefaultClient, models.Fetcher{Id: 1, Auth: tt.auth}, req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && req.Header.Get(tt.wantHeader) != tt.wantValue {
+				t.Errorf("Expected %s header %q, got: %q", tt.wantHeader, tt.wantValue, req.Header.Get(tt.wantHeader))
+			}
+		})
+	}
+}
+
+func TestWorker_Authorize_TokenCache(t *testing.T) {
+	tokenRequests := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"issued","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	s := sealed(t, "s3cret")
+	w := &Worker{storage: s, secrets: secrets.NewManager(s, testKeyring)}
+	fetcher := models.Fetcher{Id: 1, Auth: &models.Auth{Type: models.AuthOAuth2, TokenUrl: tokenServer.URL, ClientId: "fetcher"}}
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		if err := w.authorize(http.DefaultClient, fetcher, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("Expected token to be requested once, got: %d", tokenRequests)
+	}
+
+	fetcher.Auth.Scopes = []string{"read"}
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if err := w.authorize(http.DefaultClient, fetcher, req); err != nil {
+		t.Fatal(err)
+	}
+	if tokenRequests != 2 {
+		t.Errorf("Expected new token after auth change, got %d requests", tokenRequests)
+	}
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+)
+
+var errRedirect = errors.New("redirect not allowed")
+
+type redirectsKey struct{}
+
+// withRedirects returns context in which redirects checked by client are appended to given chain
+func withRedirects(ctx context.Context, chain *[]models.Redirect) context.Context {
+	return context.WithValue(ctx, redirectsKey{}, chain)
+}
+
+// checkRedirect records every redirect into chain from request context and applies fetcher redirect policy.
+// Policy none makes client return the redirect response, redirects over max hops or to other host
+// with same_host policy fail the request. Fetcher headers and credentials aren't sent to other hosts, neither is
+// body with secrets, which client replays on 307 and 308 redirects, so such redirects fail the request.
+func checkRedirect(fetcher models.Fetcher) func(req *http.Request, via []*http.Request) error {
+	policy := fetcher.Redirect
+	mode, hops := "", policy.Hops()
+	if policy != nil {
+		mode = policy.Mode
+	}
+	headers := credentialHeaders(fetcher)
+	secretBody := models.SecretReference.MatchString(fetcher.Body)
+
+	return func(req *http.Request, via []*http.Request) error {
+		if chain, ok := req.Context().Value(redirectsKey{}).(*[]models.Redirect); ok && req.Response != nil {
+			*chain = append(*chain, models.Redirect{
+				StatusCode: req.Response.StatusCode,
+				Location:   redirectLocation(req.URL),
+			})
+		}
+
+		crossHost := req.URL.Host != via[0].URL.Host
+		switch {
+		case mode == models.RedirectNone:
+			return http.ErrUseLastResponse
+		case mode == models.RedirectSameHost && crossHost:
+			return fmt.Errorf("%w: %s is not on host %s", errRedirect, req.URL.Host, via[0].URL.Host)
+		case len(via) > hops:
+			return fmt.Errorf("%w: stopped after %d redirects", errRedirect, hops)
+		}
+
+		// client copies all headers of the first request, it strips only standard credentials and only
+		// for hosts which aren't subdomains of the original one
+		if crossHost {
+			// secrets in body were resolved for the original host only
+			if secretBody && req.Body != nil && req.Body != http.NoBody {
+				return fmt.Errorf("%w: body with secrets can't be sent to %s", errRedirect, req.URL.Host)
+			}
+			for _, name := range headers {
+				req.Header.Del(name)
+			}
+		}
+
+		return nil
+	}
+}
+
+// credentialHeaders returns headers fetcher sets itself, which may carry resolved secrets or credentials
+func credentialHeaders(fetcher models.Fetcher) []string {
+	headers := make([]string, 0, len(fetcher.Headers)+1)
+	for name := range fetcher.Headers {
+		headers = append(headers, name)
+	}
+	if fetcher.Auth != nil {
+		headers = append(headers, "Authorization")
+		if fetcher.Auth.Type == models.AuthApiKey {
+			headers = append(headers, fetcher.Auth.Header)
+		}
+	}
+
+	return headers
+}
+
+// redirectLocation returns location without user info and query, which may carry secrets resolved into url
+func redirectLocation(u *url.URL) string {
+	location := *u
+	location.User = nil
+	location.RawQuery = ""
+	location.ForceQuery = false
+
+	return location.String()
+}
@@ -0,0 +1,207 @@
+package worker
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"go.opentelemetry.io/otel/api/trace"
+)
+
+func TestWorker_Fetch_Redirects(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/start", http.RedirectHandler("/login", http.StatusFound))
+	mux.Handle("/login", http.RedirectHandler("/end", http.StatusMovedPermanently))
+	mux.Handle("/other", http.RedirectHandler(other.URL, http.StatusTemporaryRedirect))
+	mux.HandleFunc("/end", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		path           string
+		policy         *models.RedirectPolicy
+		wantStatusCode int
+		wantRedirects  []int
+		wantError      string
+	}{
+		{
+			name:           "positive_follow_by_default",
+			path:           "/start",
+			wantStatusCode: http.StatusOK,
+			wantRedirects:  []int{http.StatusFound, http.StatusMovedPermanently},
+		},
+		{
+			name:           "positive_dont_follow",
+			path:           "/start",
+			policy:         &models.RedirectPolicy{Mode: models.RedirectNone},
+			wantStatusCode: http.StatusFound,
+			wantRedirects:  []int{http.StatusFound},
+		},
+		{
+			name:           "positive_same_host",
+			path:           "/start",
+			policy:         &models.RedirectPolicy{Mode: models.RedirectSameHost},
+			wantStatusCode: http.StatusOK,
+			wantRedirects:  []int{http.StatusFound, http.StatusMovedPermanently},
+		},
+		{
+			name:          "negative_same_host_error",
+			path:          "/other",
+			policy:        &models.RedirectPolicy{Mode: models.RedirectSameHost},
+			wantRedirects: []int{http.StatusTemporaryRedirect},
+			wantError:     "redirect",
+		},
+		{
+			name:          "negative_max_hops_error",
+			path:          "/start",
+			policy:        &models.RedirectPolicy{MaxHops: 1},
+			wantRedirects: []int{http.StatusFound, http.StatusMovedPermanently},
+			wantError:     "redirect",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Worker{client: http.DefaultClient, tracer: trace.NoopTracer{}}
+			client, _ := w.clientFor(models.Fetcher{Id: 1, Redirect: tt.policy})
+			req, _ := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+			history := &models.History{}
+
+			w.fetch(client, req, history)
+
+			if history.StatusCode != tt.wantStatusCode {
+				t.Errorf("Expected status code %d, got: %d", tt.wantStatusCode, history.StatusCode)
+			}
+			if (history.Error == nil && len(tt.wantError) > 0) || (history.Error != nil && *history.Error != tt.wantError) {
+				t.Errorf("Expected error %q, got: %v", tt.wantError, history.Error)
+			}
+			if len(history.Redirects) != len(tt.wantRedirects) {
+				t.Fatalf("Expected %d redirects, got: %+v", len(tt.wantRedirects), history.Redirects)
+			}
+			for i, redirect := range history.Redirects {
+				if redirect.StatusCode != tt.wantRedirects[i] || len(redirect.Location) == 0 {
+					t.Errorf("Unexpected redirect %d: %+v", i, redirect)
+				}
+			}
+		})
+	}
+}
+
+func TestWorker_Fetch_RedirectHeaders(t *testing.T) {
+	var received http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/other", http.RedirectHandler(other.URL+"/end?token=s3cret", http.StatusFound))
+	mux.Handle("/same", http.RedirectHandler("/end?token=s3cret", http.StatusFound))
+	mux.HandleFunc("/end", func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := models.Fetcher{
+		Id:      1,
+		Headers: map[string]string{"X-Custom": "s3cret"},
+		Auth:    &models.Auth{Type: models.AuthApiKey, Header: "X-Api-Key"},
+	}
+	tests := []struct {
+		name       string
+		path       string
+		wantHeader string
+	}{
+		{
+			name:       "positive_same_host_keeps_headers",
+			path:       "/same",
+			wantHeader: "s3cret",
+		},
+		{
+			name: "positive_other_host_drops_headers",
+			path: "/other",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			w := &Worker{client: http.DefaultClient, tracer: trace.NoopTracer{}}
+			client, _ := w.clientFor(fetcher)
+			req, _ := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+			req.Header.Set("X-Custom", "s3cret")
+			req.Header.Set("X-Api-Key", "s3cret")
+			history := &models.History{}
+
+			w.fetch(client, req, history)
+
+			if history.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status code %d, got: %d", http.StatusOK, history.StatusCode)
+			}
+			if got := received.Get("X-Custom"); got != tt.wantHeader {
+				t.Errorf("Expected X-Custom header %q, got: %q", tt.wantHeader, got)
+			}
+			if got := received.Get("X-Api-Key"); got != tt.wantHeader {
+				t.Errorf("Expected X-Api-Key header %q, got: %q", tt.wantHeader, got)
+			}
+			if len(history.Redirects) != 1 || strings.Contains(history.Redirects[0].Location, "s3cret") {
+				t.Errorf("Expected redirect location without query, got: %+v", history.Redirects)
+			}
+		})
+	}
+}
+
+func TestWorker_Fetch_RedirectBody(t *testing.T) {
+	var received *string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = pointer(string(body))
+	}))
+	defer other.Close()
+
+	server := httptest.NewServer(http.RedirectHandler(other.URL+"/end", http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		body      string
+		wantBody  *string
+		wantError string
+	}{
+		{
+			name:     "positive_body_without_secrets_replayed",
+			body:     `{"id":1}`,
+			wantBody: pointer(`{"id":1}`),
+		},
+		{
+			name:      "negative_body_with_secrets_error",
+			body:      `{"token":"${secret:token}"}`,
+			wantError: "redirect",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			w := &Worker{client: http.DefaultClient, tracer: trace.NoopTracer{}}
+			client, _ := w.clientFor(models.Fetcher{Id: 1, Method: http.MethodPost, Body: tt.body})
+			// body as newRequest would resolve it for the original host
+			req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(strings.ReplaceAll(tt.body, "${secret:token}", "s3cret")))
+			history := &models.History{}
+
+			w.fetch(client, req, history)
+
+			if (history.Error == nil && len(tt.wantError) > 0) || (history.Error != nil && *history.Error != tt.wantError) {
+				t.Errorf("Expected error %q, got: %v", tt.wantError, history.Error)
+			}
+			if (received == nil) != (tt.wantBody == nil) || (received != nil && *received != *tt.wantBody) {
+				t.Errorf("Expected other host to receive %v, got: %v", tt.wantBody, received)
+			}
+		})
+	}
+}
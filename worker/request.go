@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+)
+
+// prepare builds fetcher request with resolved secret references and credentials, together with client
+// to send it with. On failure it also returns kind of error to be stored on history.
+func (w *Worker) prepare(ctx context.Context, fetcher models.Fetcher) (*http.Client, *http.Request, string, error) {
+	req, err := w.newRequest(ctx, fetcher)
+	if err != nil {
+		return nil, nil, "secret", err
+	}
+
+	// fetcher is a copy, so resolved proxy credentials don't end up in stored fetcher
+	if len(fetcher.Proxy) > 0 && fetcher.Proxy != models.ProxyDirect {
+		host, err := models.SecretHost(fetcher.Proxy)
+		if err != nil {
+			return nil, nil, "secret", err
+		}
+		if fetcher.Proxy, err = w.secrets.ResolveFor(ctx, fetcher.TenantId, fetcher.Proxy, host); err != nil {
+			return nil, nil, "secret", err
+		}
+	}
+
+	client, err := w.clientFor(fetcher)
+	if err != nil {
+		return nil, nil, "transport", err
+	}
+
+	if err = w.authorize(client, fetcher, req); err != nil {
+		return nil, nil, "auth", err
+	}
+
+	return client, req, "", nil
+}
+
+// newRequest resolves secret references of url, headers and body, only secrets bound to url host can be used
+func (w *Worker) newRequest(ctx context.Context, fetcher models.Fetcher) (*http.Request, error) {
+	host, err := models.SecretHost(fetcher.Url)
+	if err != nil {
+		return nil, err
+	}
+	target, err := w.secrets.ResolveFor(ctx, fetcher.TenantId, fetcher.Url, host)
+	if err != nil {
+		return nil, err
+	}
+	body, err := w.secrets.ResolveFor(ctx, fetcher.TenantId, fetcher.Body, host)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, fetcher.RequestMethod(), target, strings.NewReader(body))
+	if err != nil {
+		// parse error repeats resolved url, so only its cause is returned
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, err
+	}
+	if len(body) == 0 {
+		req.Body = http.NoBody
+		req.ContentLength = 0
+	}
+
+	for name, value := range fetcher.Headers {
+		value, err = w.secrets.ResolveFor(ctx, fetcher.TenantId, value, host)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, value)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
+)
+
+func TestWorker_NewRequest(t *testing.T) {
+	s := &mock.Storage{}
+	sm := secrets.NewManager(s, testKeyring)
+	_ = sm.Set(context.Background(), &models.Secret{Name: "token", Value: "s3cret", Hosts: []string{"example.com"}})
+
+	tests := []struct {
+		name       string
+		manager    *secrets.Manager
+		fetcher    models.Fetcher
+		wantMethod string
+		wantUrl    string
+		wantHeader string
+		wantBody   string
+		wantErr    bool
+	}{
+		{
+			name:       "positive_default_get",
+			manager:    sm,
+			fetcher:    models.Fetcher{Url: "https://example.com"},
+			wantMethod: http.MethodGet,
+			wantUrl:    "https://example.com",
+		},
+		{
+			name:    "positive_resolved_secrets",
+			manager: sm,
+			fetcher: models.Fetcher{
+				Url:     "https://example.com/?key=${secret:token}",
+				Method:  http.MethodPost,
+				Headers: map[string]string{"Authorization": "Bearer ${secret:token}"},
+				Body:    `{"token":"${secret:token}"}`,
+			},
+			wantMethod: http.MethodPost,
+			wantUrl:    "https://example.com/?key=s3cret",
+			wantHeader: "Bearer s3cret",
+			wantBody:   `{"token":"s3cret"}`,
+		},
+		{
+			name:    "negative_missing_secret_error",
+			manager: sm,
+			fetcher: models.Fetcher{
+				Url:     "https://example.com",
+				Headers: map[string]string{"Authorization": "Bearer ${secret:missing}"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "negative_secret_not_bound_to_host_error",
+			manager: sm,
+			fetcher: models.Fetcher{Url: "https://attacker.example.org/?x=${secret:token}"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_secret_in_header_not_bound_to_host_error",
+			manager: sm,
+			fetcher: models.Fetcher{
+				Url:     "https://attacker.example.org",
+				Headers: map[string]string{"Authorization": "Bearer ${secret:token}"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "negative_secret_in_url_host_error",
+			manager: sm,
+			fetcher: models.Fetcher{Url: "https://${secret:token}.example.com/"},
+			wantErr: true,
+		},
+		{
+			name:    "negative_secret_store_not_configured_error",
+			fetcher: models.Fetcher{Url: "https://example.com/?key=${secret:token}"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Worker{storage: s, secrets: tt.manager}
+
+			req, err := w.newRequest(context.Background(), tt.fetcher)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if req.Method != tt.wantMethod || req.URL.String() != tt.wantUrl {
+				t.Errorf("Expected %s %s, got: %s %s", tt.wantMethod, tt.wantUrl, req.Method, req.URL)
+			}
+			if req.Header.Get("Authorization") != tt.wantHeader {
+				t.Errorf("Expected Authorization header %q, got: %q", tt.wantHeader, req.Header.Get("Authorization"))
+			}
+			body, _ := ioutil.ReadAll(req.Body)
+			if string(body) != tt.wantBody {
+				t.Errorf("Expected body %q, got: %q", tt.wantBody, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package worker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+)
+
+// timer collects moments of request phases reported by httptrace. Callbacks may run
+// on different goroutines (e.g. parallel dials), hence the mutex.
+type timer struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reused       bool
+
+	// peerCertificates is chain of the first completed handshake, it is known even when request fails later
+	peerCertificates []*x509.Certificate
+}
+
+func (t *timer) set(moment *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*moment = time.Now()
+}
+
+// setFirst keeps the earliest moment, for phases which may be started more than once
+func (t *timer) setFirst(moment *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if moment.IsZero() {
+		*moment = time.Now()
+	}
+}
+
+func (t *timer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.set(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.set(&t.dnsDone)
+		},
+		ConnectStart: func(string, string) {
+			t.setFirst(&t.connectStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.set(&t.connectDone)
+		},
+		TLSHandshakeStart: func() {
+			t.set(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			t.set(&t.tlsDone)
+			t.mu.Lock()
+			if err == nil && t.peerCertificates == nil {
+				t.peerCertificates = state.PeerCertificates
+			}
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.set(&t.gotConn)
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.set(&t.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			t.set(&t.firstByte)
+		},
+	}
+}
+
+// certificates returns chain of the first completed handshake, nil when connection was reused or isn't TLS
+func (t *timer) certificates() []*x509.Certificate {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.peerCertificates
+}
+
+// timings converts collected moments into phase durations, done is the moment response body was read
+func (t *timer) timings(done time.Time) *models.Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	requestWritten := t.wroteRequest
+	if requestWritten.IsZero() {
+		requestWritten = t.gotConn
+	}
+	transferDone := done
+	if t.firstByte.IsZero() {
+		transferDone = time.Time{}
+	}
+
+	return &models.Timings{
+		Dns:      seconds(t.dnsStart, t.dnsDone),
+		Connect:  seconds(t.connectStart, t.connectDone),
+		Tls:      seconds(t.tlsStart, t.tlsDone),
+		Ttfb:     seconds(requestWritten, t.firstByte),
+		Transfer: seconds(t.firstByte, transferDone),
+		Reused:   t.reused,
+	}
+}
+
+// seconds returns time between two moments, or 0 when any of them was not reached
+func seconds(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+
+	return to.Sub(from).Seconds()
+}
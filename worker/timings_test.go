@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+)
+
+func TestTimer_Timings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := server.Client()
+
+	tests := []struct {
+		name       string
+		wantReused bool
+	}{
+		{
+			name:       "positive_new_connection",
+			wantReused: false,
+		},
+		{
+			name:       "positive_reused_connection",
+			wantReused: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer := &timer{}
+			ctx := httptrace.WithClientTrace(context.Background(), timer.clientTrace())
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+			response, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = ioutil.ReadAll(response.Body)
+			response.Body.Close()
+			timings := timer.timings(time.Now())
+
+			if timings.Reused != tt.wantReused {
+				t.Errorf("Expected reused %t, got: %t", tt.wantReused, timings.Reused)
+			}
+			if timings.Ttfb < 0.01 {
+				t.Errorf("Expected ttfb at least 0.01s, got: %f", timings.Ttfb)
+			}
+			if tt.wantReused && timings.Connect != 0 {
+				t.Errorf("Expected no connect phase on reused connection, got: %f", timings.Connect)
+			}
+			if !tt.wantReused && timings.Connect <= 0 {
+				t.Errorf("Expected connect phase on new connection, got: %f", timings.Connect)
+			}
+		})
+	}
+}
+
+func TestSeconds(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want float64
+	}{
+		{
+			name: "positive_seconds",
+			from: now,
+			to:   now.Add(1500 * time.Millisecond),
+			want: 1.5,
+		},
+		{
+			name: "positive_phase_not_reached",
+			from: now,
+			want: 0,
+		},
+		{
+			name: "positive_to_before_from",
+			from: now,
+			to:   now.Add(-time.Second),
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := seconds(tt.from, tt.to); got != tt.want {
+				t.Errorf("seconds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package worker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/destination"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// transportKey holds fetcher settings transport is built from, together with modification times of its TLS files,
+// so rotated files are loaded again
+type transportKey struct {
+	tls      models.TlsConfig
+	proxy    string
+	modTimes [3]int64
+}
+
+// fetcherTransport is transport built for fetcher with custom TLS or proxy, kept together with settings it was built from
+type fetcherTransport struct {
+	key       transportKey
+	transport *http.Transport
+}
+
+// NewTransport returns transport with global proxy settings, which connects only to destinations allowed by policy.
+// Configured proxy is exempt from the policy, targets reached through it are checked by their resolved addresses.
+func NewTransport(proxy config.Proxy, policy *destination.Policy) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = policy.Proxy(newProxy(proxy))
+	transport.DialContext = policy.DialContext(&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}, proxyAddrs(proxy)...)
+
+	return transport
+}
+
+// proxyAddrs returns addresses of proxies from config or, without proxy url, from proxy env variables
+func proxyAddrs(conf config.Proxy) []string {
+	urls := []string{conf.Url}
+	if len(conf.Url) == 0 {
+		env := httpproxy.FromEnvironment()
+		urls = []string{env.HTTPProxy, env.HTTPSProxy}
+	}
+
+	var addrs []string
+	for _, rawUrl := range urls {
+		if len(rawUrl) == 0 {
+			continue
+		}
+		if !strings.Contains(rawUrl, "://") {
+			rawUrl = "http://" + rawUrl
+		}
+		if u, err := url.Parse(rawUrl); err == nil && len(u.Hostname()) > 0 {
+			addrs = append(addrs, destination.ProxyAddr(u))
+		}
+	}
+
+	return addrs
+}
+
+// newProxy returns proxy function of shared transport. Without proxy url it falls back to proxy env variables.
+func newProxy(conf config.Proxy) func(*http.Request) (*url.URL, error) {
+	if len(conf.Url) == 0 {
+		return http.ProxyFromEnvironment
+	}
+
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  conf.Url,
+		HTTPSProxy: conf.Url,
+		NoProxy:    conf.NoProxy,
+	}).ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+}
+
+// clientFor returns client with redirect policy of fetcher. Fetchers without custom TLS or proxy share transport
+// of the default client, the others get own transport, which is cached between runs so connections are reused
+// until fetcher settings change. Secret references in fetcher proxy have to be resolved before.
+func (w *Worker) clientFor(fetcher models.Fetcher) (*http.Client, error) {
+	transport, err := w.transportFor(fetcher)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: checkRedirect(fetcher),
+	}, nil
+}
+
+func (w *Worker) transportFor(fetcher models.Fetcher) (http.RoundTripper, error) {
+	if fetcher.Tls == nil && len(fetcher.Proxy) == 0 {
+		w.dropClient(fetcher.Id)
+		return w.client.Transport, nil
+	}
+
+	key := transportKey{proxy: fetcher.Proxy}
+	if fetcher.Tls != nil {
+		key.tls = *fetcher.Tls
+		key.modTimes = tlsModTimes(w.tlsDir, fetcher.Tls)
+	}
+	if cached, ok := w.clients.Load(fetcher.Id); ok && cached.(*fetcherTransport).key == key {
+		return cached.(*fetcherTransport).transport, nil
+	}
+
+	transport := w.sharedTransport().Clone()
+	if fetcher.Tls != nil {
+		tlsConfig, err := newTlsConfig(w.tlsDir, fetcher.Tls)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	switch fetcher.Proxy {
+	case "":
+	case models.ProxyDirect:
+		transport.Proxy = nil
+	default:
+		proxyUrl, err := url.Parse(fetcher.Proxy)
+		if err != nil {
+			return nil, errors.New("invalid proxy url")
+		}
+		transport.Proxy = w.policy.Proxy(http.ProxyURL(proxyUrl))
+	}
+
+	w.dropClient(fetcher.Id)
+	w.clients.Store(fetcher.Id, &fetcherTransport{key: key, transport: transport})
+
+	return transport, nil
+}
+
+// sharedTransport returns transport of the default client, which carries global proxy settings
+func (w *Worker) sharedTransport() *http.Transport {
+	if transport, ok := w.client.Transport.(*http.Transport); ok {
+		return transport
+	}
+
+	return http.DefaultTransport.(*http.Transport)
+}
+
+func (w *Worker) dropClient(fetcherId int) {
+	if cached, ok := w.clients.Load(fetcherId); ok {
+		w.clients.Delete(fetcherId)
+		cached.(*fetcherTransport).transport.CloseIdleConnections()
+	}
+}
+
+// tlsPath resolves path of fetcher TLS file within tls directory, files outside of it can't be read
+func tlsPath(dir, path string) (string, error) {
+	if len(dir) == 0 {
+		return "", errors.New("tls files aren't allowed, tls directory isn't configured")
+	}
+	resolved := filepath.Join(dir, path)
+	if !strings.HasPrefix(resolved, filepath.Clean(dir)+string(filepath.Separator)) {
+		return "", fmt.Errorf("tls file %q is outside of tls directory", path)
+	}
+
+	return resolved, nil
+}
+
+// tlsModTimes returns modification times of fetcher TLS files, unset and unreadable ones are left zero
+func tlsModTimes(dir string, conf *models.TlsConfig) [3]int64 {
+	var modTimes [3]int64
+	for i, path := range conf.Files() {
+		if len(path) == 0 {
+			continue
+		}
+		resolved, err := tlsPath(dir, path)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(resolved); err == nil {
+			modTimes[i] = info.ModTime().UnixNano()
+		}
+	}
+
+	return modTimes
+}
+
+func newTlsConfig(dir string, conf *models.TlsConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         conf.TlsVersion(),
+		ServerName:         conf.ServerName,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+
+	if len(conf.CaBundle) > 0 {
+		caBundle, err := tlsPath(dir, conf.CaBundle)
+		if err != nil {
+			return nil, err
+		}
+		pem, err := ioutil.ReadFile(caBundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in ca bundle")
+		}
+	}
+
+	if len(conf.ClientCert) > 0 {
+		certFile, err := tlsPath(dir, conf.ClientCert)
+		if err != nil {
+			return nil, err
+		}
+		keyFile, err := tlsPath(dir, conf.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
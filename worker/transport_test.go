@@ -0,0 +1,218 @@
+package worker
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+)
+
+func TestWorker_ClientFor(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "fetcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caBundle := filepath.Join(dir, "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err = ioutil.WriteFile(caBundle, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		tlsDir         string
+		tls            *models.TlsConfig
+		wantClientErr  bool
+		wantRequestErr bool
+	}{
+		{
+			name:           "negative_untrusted_certificate_error",
+			tls:            nil,
+			wantRequestErr: true,
+		},
+		{
+			name:   "positive_custom_ca_bundle",
+			tlsDir: dir,
+			tls:    &models.TlsConfig{CaBundle: "ca.pem", MinVersion: "1.2"},
+		},
+		{
+			name: "positive_insecure_skip_verify",
+			tls:  &models.TlsConfig{InsecureSkipVerify: true},
+		},
+		{
+			name:          "negative_missing_ca_bundle_error",
+			tlsDir:        dir,
+			tls:           &models.TlsConfig{CaBundle: "missing.pem"},
+			wantClientErr: true,
+		},
+		{
+			name:          "negative_missing_client_cert_error",
+			tlsDir:        dir,
+			tls:           &models.TlsConfig{ClientCert: "cert.pem", ClientKey: "key.pem"},
+			wantClientErr: true,
+		},
+		{
+			name:          "negative_tls_dir_not_configured_error",
+			tls:           &models.TlsConfig{CaBundle: "ca.pem"},
+			wantClientErr: true,
+		},
+		{
+			name:          "negative_file_outside_tls_dir_error",
+			tlsDir:        filepath.Join(dir, "certs"),
+			tls:           &models.TlsConfig{CaBundle: "../ca.pem"},
+			wantClientErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Worker{client: http.DefaultClient, tlsDir: tt.tlsDir}
+			fetcher := models.Fetcher{Id: 1, Url: server.URL, Tls: tt.tls}
+
+			client, err := w.clientFor(fetcher)
+			if (err != nil) != tt.wantClientErr {
+				t.Fatalf("clientFor() error = %v, wantErr %v", err, tt.wantClientErr)
+			}
+			if err != nil {
+				return
+			}
+
+			response, err := client.Get(server.URL)
+			if (err != nil) != tt.wantRequestErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantRequestErr)
+			}
+			if err == nil {
+				response.Body.Close()
+			}
+		})
+	}
+}
+
+func TestWorker_ClientFor_Cache(t *testing.T) {
+	w := &Worker{client: http.DefaultClient}
+	fetcher := models.Fetcher{Id: 1, Tls: &models.TlsConfig{ServerName: "example.com"}}
+
+	first, _ := w.clientFor(fetcher)
+	second, _ := w.clientFor(fetcher)
+	if first.Transport != second.Transport {
+		t.Errorf("Expected transport to be reused while tls config is unchanged")
+	}
+
+	fetcher.Tls = &models.TlsConfig{ServerName: "example.org"}
+	third, _ := w.clientFor(fetcher)
+	if third.Transport == second.Transport {
+		t.Errorf("Expected new transport after tls config change")
+	}
+
+	fetcher.Tls = nil
+	if client, _ := w.clientFor(fetcher); client.Transport != http.DefaultClient.Transport {
+		t.Errorf("Expected shared transport for fetcher without tls config")
+	}
+	if _, ok := w.clients.Load(fetcher.Id); ok {
+		t.Errorf("Expected cached client to be dropped")
+	}
+}
+
+func TestWorker_ClientFor_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.Host+" "+r.Header.Get("Proxy-Authorization"))
+	}))
+	defer proxy.Close()
+
+	tests := []struct {
+		name        string
+		global      config.Proxy
+		proxy       string
+		wantProxied string
+	}{
+		{
+			name:        "positive_global_proxy",
+			global:      config.Proxy{Url: proxy.URL},
+			wantProxied: "example.invalid ",
+		},
+		{
+			name:   "positive_global_proxy_excluded_host",
+			global: config.Proxy{Url: proxy.URL, NoProxy: ".invalid"},
+		},
+		{
+			name:   "positive_direct_override",
+			global: config.Proxy{Url: proxy.URL},
+			proxy:  models.ProxyDirect,
+		},
+		{
+			name:        "positive_fetcher_proxy_with_auth",
+			proxy:       "http://fetcher:s3cret@" + proxy.Listener.Addr().String(),
+			wantProxied: "example.invalid Basic ZmV0Y2hlcjpzM2NyZXQ=",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxied = nil
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.Proxy = newProxy(tt.global)
+			w := &Worker{client: &http.Client{Transport: transport}}
+
+			client, err := w.clientFor(models.Fetcher{Id: 1, Proxy: tt.proxy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := client.Get("http://example.invalid/")
+			if err == nil {
+				response.Body.Close()
+			}
+
+			if len(tt.wantProxied) == 0 && len(proxied) > 0 {
+				t.Errorf("Expected request not to be proxied, got: %v", proxied)
+			}
+			if len(tt.wantProxied) > 0 && (len(proxied) != 1 || proxied[0] != tt.wantProxied) {
+				t.Errorf("Expected proxied request %q, got: %v", tt.wantProxied, proxied)
+			}
+		})
+	}
+}
+
+func TestWorker_ClientFor_RotatedFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "fetcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caBundle := filepath.Join(dir, "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err = ioutil.WriteFile(caBundle, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &Worker{client: http.DefaultClient, tlsDir: dir}
+	fetcher := models.Fetcher{Id: 1, Tls: &models.TlsConfig{CaBundle: "ca.pem"}}
+	first, err := w.clientFor(fetcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modTime := time.Now().Add(time.Minute)
+	if err = os.Chtimes(caBundle, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	second, err := w.clientFor(fetcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Transport == second.Transport {
+		t.Errorf("Expected new transport after ca bundle was rotated")
+	}
+}
@@ -2,16 +2,37 @@ package worker
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/BarTar213/bartlomiej-tarczynski/alert"
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/destination"
+	"github.com/BarTar213/bartlomiej-tarczynski/logging"
+	"github.com/BarTar213/bartlomiej-tarczynski/metrics"
 	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/BarTar213/bartlomiej-tarczynski/notifier"
+	"github.com/BarTar213/bartlomiej-tarczynski/secrets"
 	"github.com/BarTar213/bartlomiej-tarczynski/storage"
 	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/propagation"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/semconv"
 )
 
 type Worker struct {
@@ -19,80 +40,327 @@ type Worker struct {
 	storage     storage.Storage
 	client      *http.Client
 	historyPool *sync.Pool
-	logger      *log.Logger
+	alerts      *alert.Manager
+	dispatcher  *notifier.Dispatcher
+	metrics     *metrics.Metrics
+	jobs        sync.Map
+	contents    sync.Map
+	clients     sync.Map
+	tokens      sync.Map
+	certWarned  sync.Map
+	certWarning time.Duration
+	running     int32
+	synced      int32
+	tracer      trace.Tracer
+	secrets     *secrets.Manager
+	policy      *destination.Policy
+	tlsDir      string
+	logger      logrus.FieldLogger
 }
 
-func New(storage storage.Storage, historyPool *sync.Pool, l *log.Logger) *Worker {
+func New(storage storage.Storage, historyPool *sync.Pool, alerts *alert.Manager, dispatcher *notifier.Dispatcher, m *metrics.Metrics, tracer trace.Tracer, sm *secrets.Manager, proxy config.Proxy, destinations *destination.Policy, tlsDir string, certWarning time.Duration, l logrus.FieldLogger) *Worker {
 	c := cron.New(cron.WithSeconds())
 	c.Start()
+	m.RegisterCronEntries(func() int {
+		return len(c.Entries())
+	})
+
 	return &Worker{
 		c:           c,
 		storage:     storage,
 		historyPool: historyPool,
-		client:      http.DefaultClient,
+		alerts:      alerts,
+		dispatcher:  dispatcher,
+		metrics:     m,
+		client:      &http.Client{Transport: NewTransport(proxy, destinations)},
+		running:     1,
+		certWarning: certWarning,
+		tracer:      tracer,
+		secrets:     sm,
+		policy:      destinations,
+		tlsDir:      tlsDir,
 		logger:      l,
 	}
 }
 
+// Resync registers jobs of all stored fetchers which aren't paused. Jobs live only in memory,
+// so it has to be called once after start.
+func (w *Worker) Resync(ctx context.Context) error {
+	fetchers, err := w.storage.GetAllFetchers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range fetchers {
+		if fetchers[i].Paused {
+			continue
+		}
+		if err = w.RegisterJob(&fetchers[i]); err != nil {
+			return err
+		}
+		if err = w.storage.UpdateFetcherJobId(ctx, fetchers[i].Id, fetchers[i].JobId); err != nil {
+			return err
+		}
+	}
+	atomic.StoreInt32(&w.synced, 1)
+
+	return nil
+}
+
+func (w *Worker) Running() bool {
+	return atomic.LoadInt32(&w.running) == 1
+}
+
+func (w *Worker) Synced() bool {
+	return atomic.LoadInt32(&w.synced) == 1
+}
+
 func (w *Worker) RegisterJob(fetcher *models.Fetcher) error {
-	url := fetcher.Url
-	id := fetcher.Id
+	definition := &atomic.Value{}
+	definition.Store(*fetcher)
 	entryID, err := w.c.AddFunc(fmt.Sprintf("@every %ds", fetcher.Interval), func() {
-		w.processJob(url, id)
+		w.processJob(definition.Load().(models.Fetcher))
 	})
 	if err != nil {
 		return err
 	}
 
 	fetcher.JobId = int(entryID)
+	w.jobs.Store(fetcher.JobId, definition)
 	return nil
 }
 
+// RefreshJob replaces fetcher definition used by its job without rescheduling it, so the interval mustn't change.
+// Fetcher whose job isn't registered gets a new one.
+func (w *Worker) RefreshJob(fetcher *models.Fetcher) error {
+	if definition, ok := w.jobs.Load(fetcher.JobId); ok {
+		definition.(*atomic.Value).Store(*fetcher)
+		return nil
+	}
+
+	return w.RegisterJob(fetcher)
+}
+
+// Jobs returns number of scheduled jobs
+func (w *Worker) Jobs() int {
+	return len(w.c.Entries())
+}
+
 func (w *Worker) DeregisterJob(id int) {
 	w.c.Remove(cron.EntryID(id))
+	w.jobs.Delete(id)
 }
 
-func (w Worker) UpdateJob(fetcher *models.Fetcher, id int) error {
+// Forget drops state kept for fetcher between runs, it should be called after fetcher is deleted
+func (w *Worker) Forget(fetcherId int) {
+	w.contents.Delete(fetcherId)
+	w.certWarned.Delete(fetcherId)
+	w.dropClient(fetcherId)
+	w.tokens.Delete(fetcherId)
+	w.metrics.DeleteFetcher(fetcherId)
+}
+
+func (w *Worker) UpdateJob(fetcher *models.Fetcher, id int) error {
 	w.DeregisterJob(id)
 	return w.RegisterJob(fetcher)
 }
 
-func (w *Worker) processJob(url string, fetcherId int) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+func (w *Worker) processJob(fetcher models.Fetcher) {
+	w.metrics.JobsInFlight.Inc()
+	defer w.metrics.JobsInFlight.Dec()
+
+	logger := w.logger.WithFields(logrus.Fields{
+		logging.FetcherIdKey: fetcher.Id,
+		logging.RunIdKey:     logging.NewId(),
+	})
+
+	jobCtx, span := w.tracer.Start(context.Background(), "fetcher.job", trace.WithAttributes(label.Int("fetcher.id", fetcher.Id)))
+	defer span.End()
+
+	// request timeout doesn't apply to storing its results
+	ctx, cancel := context.WithTimeout(jobCtx, time.Second*5)
 	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		w.logger.Printf("NewRequest err: %s", err)
-		return
-	}
 
 	history := w.historyPool.Get().(*models.History)
 	history.Duration = 5
 	defer w.ReturnHistoryItem(history)
 
 	t := time.Now()
-	response, err := w.client.Do(req)
-	duration := time.Since(t).Seconds()
-	if err == nil {
-		defer response.Body.Close()
-		body, err := ioutil.ReadAll(response.Body)
-		if err == nil {
-			history.Response = pointer(string(body))
-		}
+	var duration float64
+	client, req, kind, err := w.prepare(ctx, fetcher)
+	if err != nil {
+		span.RecordError(ctx, err)
+		logger.WithError(err).WithField("error_kind", kind).Error("Request error")
+		history.Error = pointer(kind)
+	} else {
+		duration = w.fetch(client, req, history)
 	}
 
 	if duration < 5 {
 		history.Duration = duration
 	}
 
-	history.FetcherId = fetcherId
+	history.FetcherId = fetcher.Id
+	history.Revision = fetcher.Revision
 	history.CreatedAt = t.Unix()
+	w.metrics.ObserveFetch(fetcher.Id, history.StatusCode, history.Duration, history.Error == nil)
 
-	err = w.storage.AddHistory(history)
+	start := time.Now()
+	err = w.storage.AddHistory(jobCtx, history)
+	w.metrics.AddHistoryDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
-		w.logger.Printf("AddHistory err: %s", err)
+		w.metrics.AddHistoryErrors.Inc()
+		logger.WithError(err).Error("AddHistory error")
 		return
 	}
+
+	entry := logger.WithFields(logrus.Fields{
+		"status_code": history.StatusCode,
+		"duration":    history.Duration,
+	})
+	if history.Error != nil {
+		entry = entry.WithField("error", *history.Error)
+	}
+	entry.Debug("Fetched")
+
+	w.checkCert(jobCtx, logger, fetcher, history)
+	w.notify(fetcher, history)
+	for _, a := range w.alerts.Evaluate(jobCtx, logger, fetcher, t) {
+		eventType := models.EventAlertFired
+		if a.State == models.AlertResolved {
+			eventType = models.EventAlertResolved
+		}
+		alert := a
+		w.dispatcher.Dispatch(models.Event{
+			Type:      eventType,
+			TenantId:  fetcher.TenantId,
+			FetcherId: fetcher.Id,
+			Url:       fetcher.Url,
+			Time:      t.Unix(),
+			Alert:     &alert,
+		})
+	}
+}
+
+// fetch sends fetcher request within its own client span, fills status code, error, response and timings of history
+// and returns time (in seconds) it took to receive the response
+func (w *Worker) fetch(client *http.Client, req *http.Request, history *models.History) float64 {
+	// full url is left out of attributes, as it may contain resolved secrets
+	ctx, span := w.tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(req.Method),
+			semconv.HTTPSchemeKey.String(req.URL.Scheme),
+			semconv.HTTPHostKey.String(req.URL.Host),
+		),
+	)
+	defer span.End()
+	propagation.InjectHTTP(ctx, global.Propagators(), req.Header)
+	timer := &timer{}
+	var redirects []models.Redirect
+	req = req.WithContext(withRedirects(httptrace.WithClientTrace(ctx, timer.clientTrace()), &redirects))
+
+	t := time.Now()
+	response, err := client.Do(req)
+	duration := time.Since(t).Seconds()
+	history.Redirects = redirects
+	span.SetAttributes(label.Int("http.redirects", len(redirects)))
+	if err != nil {
+		history.Timings = timer.timings(time.Now())
+		setCertificates(history, timer.certificates())
+		history.Error = pointer(errorKind(err))
+		// url.Error repeats request url, which may contain resolved secrets
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		span.RecordError(ctx, err)
+		span.SetStatus(codes.Unavailable, *history.Error)
+		return duration
+	}
+	defer response.Body.Close()
+
+	history.StatusCode = response.StatusCode
+	certificates := timer.certificates()
+	if certificates == nil && response.TLS != nil {
+		// reused connection has no handshake to trace
+		certificates = response.TLS.PeerCertificates
+	}
+	setCertificates(history, certificates)
+	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(response.StatusCode)...)
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(response.StatusCode))
+	if response.StatusCode >= http.StatusBadRequest {
+		history.Error = pointer(fmt.Sprintf("status %d", response.StatusCode))
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	history.Timings = timer.timings(time.Now())
+	if err == nil {
+		history.Response = pointer(string(body))
+	}
+
+	return duration
+}
+
+// checkCert stores certificate expiry on fetcher and dispatches warning once it enters the warning window
+func (w *Worker) checkCert(ctx context.Context, logger logrus.FieldLogger, fetcher models.Fetcher, history *models.History) {
+	if history.CertExpiresAt == nil {
+		return
+	}
+	expiresAt := *history.CertExpiresAt
+
+	err := w.storage.UpdateFetcherCertExpiry(ctx, fetcher.Id, expiresAt)
+	if err != nil {
+		logger.WithError(err).Error("UpdateFetcherCertExpiry error")
+	}
+
+	if !models.CertExpiring(expiresAt, time.Unix(history.CreatedAt, 0), w.certWarning) {
+		w.certWarned.Delete(fetcher.Id)
+		return
+	}
+	if _, warned := w.certWarned.LoadOrStore(fetcher.Id, true); warned {
+		return
+	}
+
+	logger.WithField("cert_expires_at", expiresAt).Warn("Certificate expiring")
+	w.dispatcher.Dispatch(models.Event{
+		Type:          models.EventCertExpiring,
+		TenantId:      fetcher.TenantId,
+		FetcherId:     fetcher.Id,
+		Url:           fetcher.Url,
+		Time:          history.CreatedAt,
+		CertExpiresAt: &expiresAt,
+	})
+}
+
+func (w *Worker) notify(fetcher models.Fetcher, history *models.History) {
+	event := models.Event{
+		TenantId:   fetcher.TenantId,
+		FetcherId:  fetcher.Id,
+		Url:        fetcher.Url,
+		Time:       history.CreatedAt,
+		StatusCode: history.StatusCode,
+		Duration:   history.Duration,
+		Error:      history.Error,
+	}
+
+	if history.Error != nil {
+		event.Type = models.EventFetchFailed
+		w.dispatcher.Dispatch(event)
+		return
+	}
+
+	if history.Response == nil {
+		return
+	}
+
+	// first successful run only stores the checksum, there is nothing to compare with yet
+	sum := sha256.Sum256([]byte(*history.Response))
+	previous, loaded := w.contents.Load(fetcher.Id)
+	w.contents.Store(fetcher.Id, sum)
+	if loaded && previous.([sha256.Size]byte) != sum {
+		event.Type = models.EventContentChanged
+		w.dispatcher.Dispatch(event)
+	}
 }
 
 func (w *Worker) ReturnHistoryItem(h *models.History) {
@@ -101,6 +369,7 @@ func (w *Worker) ReturnHistoryItem(h *models.History) {
 }
 
 func (w *Worker) Stop() {
+	atomic.StoreInt32(&w.running, 0)
 	ctx := w.c.Stop()
 	for {
 		select {
@@ -110,6 +379,52 @@ func (w *Worker) Stop() {
 	}
 }
 
+// setCertificates records peer certificate chain and its expiry in history
+func setCertificates(history *models.History, certificates []*x509.Certificate) {
+	if len(certificates) == 0 {
+		return
+	}
+	for _, cert := range certificates {
+		history.Certificates = append(history.Certificates, models.NewCertificate(cert))
+	}
+	history.CertExpiresAt = models.CertExpiresAt(history.Certificates)
+}
+
+// errorKind maps request error to a short category, so failures can be grouped in stats
+func errorKind(err error) string {
+	if errors.Is(err, destination.ErrBlocked) {
+		return "blocked"
+	}
+	if errors.Is(err, errRedirect) {
+		return "redirect"
+	}
+
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var recordHeader tls.RecordHeaderError
+	if errors.As(err, &unknownAuthority) || errors.As(err, &invalidCert) || errors.As(err, &hostname) || errors.As(err, &recordHeader) {
+		return "tls"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return "dns"
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return "connection"
+	}
+
+	return "request"
+}
+
 func pointer(s string) *string {
 	return &s
 }
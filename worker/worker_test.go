@@ -0,0 +1,167 @@
+package worker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BarTar213/bartlomiej-tarczynski/config"
+	"github.com/BarTar213/bartlomiej-tarczynski/destination"
+	"github.com/BarTar213/bartlomiej-tarczynski/metrics"
+	"github.com/BarTar213/bartlomiej-tarczynski/mock"
+	"github.com/BarTar213/bartlomiej-tarczynski/models"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/api/trace"
+)
+
+func TestWorker_Fetch(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	tests := []struct {
+		name      string
+		server    *httptest.Server
+		wantCerts bool
+	}{
+		{
+			name:      "positive_fetch_http",
+			server:    httptest.NewServer(handler),
+			wantCerts: false,
+		},
+		{
+			name:      "positive_fetch_https_certificates",
+			server:    httptest.NewTLSServer(handler),
+			wantCerts: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.server.Close()
+			w := &Worker{tracer: trace.NoopTracer{}}
+			req, _ := http.NewRequest(http.MethodGet, tt.server.URL, nil)
+			history := &models.History{}
+
+			w.fetch(tt.server.Client(), req, history)
+
+			if history.StatusCode != http.StatusOK || history.Response == nil || *history.Response != "ok" {
+				t.Fatalf("Unexpected history: %+v", history)
+			}
+			if history.Timings == nil {
+				t.Errorf("Expected timings to be recorded")
+			}
+			if (len(history.Certificates) > 0) != tt.wantCerts {
+				t.Errorf("Expected certificates: %t, got: %v", tt.wantCerts, history.Certificates)
+			}
+			if tt.wantCerts {
+				cert := tt.server.Certificate()
+				if history.CertExpiresAt == nil || *history.CertExpiresAt != cert.NotAfter.Unix() {
+					t.Errorf("Expected cert expiry %d, got: %v", cert.NotAfter.Unix(), history.CertExpiresAt)
+				}
+			}
+		})
+	}
+}
+
+func TestWorker_Fetch_Errors(t *testing.T) {
+	slow := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-slow
+		}
+	}))
+	defer server.Close()
+	defer close(slow)
+
+	timeoutClient := server.Client()
+	timeoutClient.Timeout = 100 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		client    *http.Client
+		path      string
+		wantError string
+		wantCerts bool
+	}{
+		{
+			name:      "negative_unknown_authority_tls_error",
+			client:    &http.Client{},
+			wantError: "tls",
+			wantCerts: false,
+		},
+		{
+			name:      "negative_timeout_after_handshake_keeps_certificates",
+			client:    timeoutClient,
+			path:      "/slow",
+			wantError: "timeout",
+			wantCerts: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Worker{tracer: trace.NoopTracer{}}
+			req, _ := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+			history := &models.History{}
+
+			w.fetch(tt.client, req, history)
+
+			if history.Error == nil || *history.Error != tt.wantError {
+				t.Errorf("Expected %s error, got: %v", tt.wantError, history.Error)
+			}
+			if (len(history.Certificates) > 0) != tt.wantCerts {
+				t.Errorf("Expected certificates: %t, got: %v", tt.wantCerts, history.Certificates)
+			}
+		})
+	}
+}
+
+func TestWorker_Fetch_BlockedDestination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	policy, _ := destination.NewPolicy(config.Destinations{})
+	w := New(&mock.Storage{}, nil, nil, nil, metrics.New(), trace.NoopTracer{}, nil, config.Proxy{}, policy, "", 0, logrus.New())
+	defer w.Stop()
+	client, _ := w.clientFor(models.Fetcher{Id: 1})
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	history := &models.History{}
+
+	w.fetch(client, req, history)
+
+	if history.Error == nil || *history.Error != "blocked" {
+		t.Errorf("Expected blocked error, got: %v", history.Error)
+	}
+}
+
+func TestWorker_RefreshJob(t *testing.T) {
+	policy, _ := destination.NewPolicy(config.Destinations{})
+	w := New(&mock.Storage{}, nil, nil, nil, metrics.New(), trace.NoopTracer{}, nil, config.Proxy{}, policy, "", 0, logrus.New())
+	defer w.Stop()
+
+	fetcher := &models.Fetcher{Id: 1, Url: "http://example.com", Interval: 60}
+	if err := w.RegisterJob(fetcher); err != nil {
+		t.Fatalf("RegisterJob() error = %v", err)
+	}
+	jobId := fetcher.JobId
+
+	fetcher.Url = "http://example.com/changed"
+	if err := w.RefreshJob(fetcher); err != nil {
+		t.Fatalf("RefreshJob() error = %v", err)
+	}
+	if fetcher.JobId != jobId || len(w.c.Entries()) != 1 {
+		t.Errorf("Expected job %d kept as the only one, got job %d and %d entries", jobId, fetcher.JobId, len(w.c.Entries()))
+	}
+	definition, _ := w.jobs.Load(jobId)
+	if got := definition.(*atomic.Value).Load().(models.Fetcher); got.Url != fetcher.Url {
+		t.Errorf("Expected job to use refreshed url, got %q", got.Url)
+	}
+
+	w.DeregisterJob(jobId)
+	if err := w.RefreshJob(fetcher); err != nil {
+		t.Fatalf("RefreshJob() error = %v", err)
+	}
+	if fetcher.JobId == jobId || len(w.c.Entries()) != 1 {
+		t.Errorf("Expected new job registered for fetcher without one, got job %d and %d entries", fetcher.JobId, len(w.c.Entries()))
+	}
+}